@@ -9,6 +9,10 @@ import (
 
 type AuthController interface {
 	LoginUser(c *gin.Context)
+	RefreshToken(c *gin.Context)
+	LogoutUser(c *gin.Context)
+	Me(c *gin.Context)
+	Can(c *gin.Context)
 }
 
 // AuthControllerImpl is the implementation of the AuthController interface.
@@ -30,3 +34,27 @@ func (ac *AuthControllerImpl) LoginUser(c *gin.Context) {
 		handlers.ResponseFormatter(c, response.Status, response.Data, response.Message)
 	}
 }
+
+// RefreshToken handles the request to rotate refresh token and get new access token.
+func (ac *AuthControllerImpl) RefreshToken(c *gin.Context) {
+	response := ac.service.Refresh(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// LogoutUser handles the request to revoke the current token and session.
+func (ac *AuthControllerImpl) LogoutUser(c *gin.Context) {
+	response := ac.service.Logout(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// Me handles the request to get current user profile and accessible modules.
+func (ac *AuthControllerImpl) Me(c *gin.Context) {
+	response := ac.service.Me(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// Can handles the request to check access of current user to several features at once.
+func (ac *AuthControllerImpl) Can(c *gin.Context) {
+	response := ac.service.Can(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/helpers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSController interface {
+	GetJWKS(c *gin.Context)
+}
+
+// JWKSControllerImpl is the implementation of the JWKSController interface.
+type JWKSControllerImpl struct{}
+
+// JWKSControllerConstructor creates a new instance of JWKSControllerImpl.
+func JWKSControllerConstructor() JWKSController {
+	return &JWKSControllerImpl{}
+}
+
+// GetJWKS handles the request to get public keys used to verify token.
+// Response is written in standard JWKS format instead of the response formatter
+// so any jwt library could consume it directly.
+func (jc *JWKSControllerImpl) GetJWKS(c *gin.Context) {
+	jwks, err := helpers.GetJWKS()
+	if err != nil {
+		handlers.ResponseFormatter(c, http.StatusInternalServerError, nil, "Failed to load signing keys")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
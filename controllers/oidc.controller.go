@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCController interface {
+	LoginOIDC(c *gin.Context)
+	CallbackOIDC(c *gin.Context)
+}
+
+// OIDCControllerImpl is the implementation of the OIDCController interface.
+type OIDCControllerImpl struct {
+	service service.OIDCService
+}
+
+// OIDCControllerConstructor creates a new instance of OIDCControllerImpl.
+func OIDCControllerConstructor(service service.OIDCService) OIDCController {
+	return &OIDCControllerImpl{service: service}
+}
+
+// LoginOIDC handles the request to start login through identity provider.
+func (oc *OIDCControllerImpl) LoginOIDC(c *gin.Context) {
+	response := oc.service.Login(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// CallbackOIDC handles the callback from identity provider and issue token.
+func (oc *OIDCControllerImpl) CallbackOIDC(c *gin.Context) {
+	response := oc.service.Callback(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordResetController interface {
+	ForgotPassword(c *gin.Context)
+	ResetPassword(c *gin.Context)
+	ChangeExpiredPassword(c *gin.Context)
+}
+
+// PasswordResetControllerImpl is the implementation of the PasswordResetController interface.
+type PasswordResetControllerImpl struct {
+	service service.PasswordResetService
+}
+
+// PasswordResetControllerConstructor creates a new instance of PasswordResetControllerImpl.
+func PasswordResetControllerConstructor(service service.PasswordResetService) PasswordResetController {
+	return &PasswordResetControllerImpl{service: service}
+}
+
+// ForgotPassword handles the request to send password reset link by email.
+func (pc *PasswordResetControllerImpl) ForgotPassword(c *gin.Context) {
+	response := pc.service.ForgotPassword(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// ResetPassword handles the request to set new password using reset token.
+func (pc *PasswordResetControllerImpl) ResetPassword(c *gin.Context) {
+	response := pc.service.ResetPassword(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// ChangeExpiredPassword handles the request to change expired password before login.
+func (pc *PasswordResetControllerImpl) ChangeExpiredPassword(c *gin.Context) {
+	response := pc.service.ChangeExpiredPassword(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RegistrationController interface {
+	Register(c *gin.Context)
+	VerifyEmail(c *gin.Context)
+	ResendVerification(c *gin.Context)
+}
+
+// RegistrationControllerImpl is the implementation of the RegistrationController interface.
+type RegistrationControllerImpl struct {
+	service service.RegistrationService
+}
+
+// RegistrationControllerConstructor creates a new instance of RegistrationControllerImpl.
+func RegistrationControllerConstructor(service service.RegistrationService) RegistrationController {
+	return &RegistrationControllerImpl{service: service}
+}
+
+// Register handles the request of vendor self-registration.
+func (rc *RegistrationControllerImpl) Register(c *gin.Context) {
+	response := rc.service.Register(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// VerifyEmail handles the request to verify email using token from verification email.
+func (rc *RegistrationControllerImpl) VerifyEmail(c *gin.Context) {
+	response := rc.service.VerifyEmail(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// ResendVerification handles the request to send new verification email.
+func (rc *RegistrationControllerImpl) ResendVerification(c *gin.Context) {
+	response := rc.service.ResendVerification(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoutePermissionController interface {
+	GetAllRoutePermissions(c *gin.Context)
+}
+
+// RoutePermissionControllerImpl is the implementation of the RoutePermissionController interface.
+type RoutePermissionControllerImpl struct {
+	service service.RoutePermissionService
+}
+
+// RoutePermissionControllerConstructor creates a new instance of RoutePermissionControllerImpl.
+func RoutePermissionControllerConstructor(service service.RoutePermissionService) RoutePermissionController {
+	return &RoutePermissionControllerImpl{service: service}
+}
+
+// GetAllRoutePermissions handles the request to list features required by every route.
+func (rc *RoutePermissionControllerImpl) GetAllRoutePermissions(c *gin.Context) {
+	response := rc.service.GetAll(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorController interface {
+	VerifyTwoFactor(c *gin.Context)
+	EnrollTwoFactor(c *gin.Context)
+	ActivateTwoFactor(c *gin.Context)
+	DisableTwoFactor(c *gin.Context)
+}
+
+// TwoFactorControllerImpl is the implementation of the TwoFactorController interface.
+type TwoFactorControllerImpl struct {
+	service service.TwoFactorService
+}
+
+// TwoFactorControllerConstructor creates a new instance of TwoFactorControllerImpl.
+func TwoFactorControllerConstructor(service service.TwoFactorService) TwoFactorController {
+	return &TwoFactorControllerImpl{service: service}
+}
+
+// VerifyTwoFactor handles the request to finish login using two factor code.
+func (tc *TwoFactorControllerImpl) VerifyTwoFactor(c *gin.Context) {
+	response := tc.service.Verify(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// EnrollTwoFactor handles the request to generate new TOTP secret.
+func (tc *TwoFactorControllerImpl) EnrollTwoFactor(c *gin.Context) {
+	response := tc.service.Enroll(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// ActivateTwoFactor handles the request to confirm enrollment and enable two factor.
+func (tc *TwoFactorControllerImpl) ActivateTwoFactor(c *gin.Context) {
+	response := tc.service.Activate(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// DisableTwoFactor handles the request to disable two factor.
+func (tc *TwoFactorControllerImpl) DisableTwoFactor(c *gin.Context) {
+	response := tc.service.Disable(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoginHistoryController interface {
+	GetMyLoginHistory(c *gin.Context)
+	GetUserLoginHistory(c *gin.Context)
+	GetAllLoginHistory(c *gin.Context)
+}
+
+// LoginHistoryControllerImpl is the implementation of the LoginHistoryController interface.
+type LoginHistoryControllerImpl struct {
+	service service.LoginHistoryService
+}
+
+// LoginHistoryControllerConstructor creates a new instance of LoginHistoryControllerImpl.
+func LoginHistoryControllerConstructor(service service.LoginHistoryService) LoginHistoryController {
+	return &LoginHistoryControllerImpl{service: service}
+}
+
+// GetMyLoginHistory handles the request to get login history of current user.
+func (lc *LoginHistoryControllerImpl) GetMyLoginHistory(c *gin.Context) {
+	response := lc.service.GetMine(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// GetUserLoginHistory handles the request to get login history of a user by admin.
+func (lc *LoginHistoryControllerImpl) GetUserLoginHistory(c *gin.Context) {
+	response := lc.service.GetByUser(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// GetAllLoginHistory handles the request to search login history of every user by admin.
+func (lc *LoginHistoryControllerImpl) GetAllLoginHistory(c *gin.Context) {
+	response := lc.service.GetAll(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type USRPolicyController interface {
+	GetAllPolicies(c *gin.Context)
+	GetPolicy(c *gin.Context)
+	CreatePolicy(c *gin.Context)
+	UpdatePolicy(c *gin.Context)
+	DeletePolicy(c *gin.Context)
+	DryRunPolicy(c *gin.Context)
+}
+
+// PolicyControllerImpl is the implementation of the USRPolicyController interface.
+type PolicyControllerImpl struct {
+	service service.PolicyService
+}
+
+// PolicyControllerConstructor creates a new instance of PolicyControllerImpl.
+func PolicyControllerConstructor(service service.PolicyService) USRPolicyController {
+	return &PolicyControllerImpl{service: service}
+}
+
+// GetAllPolicies handles the request to get all policies.
+func (pc *PolicyControllerImpl) GetAllPolicies(c *gin.Context) {
+	response := pc.service.GetAll(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// GetPolicy handles the request to get a policy by ID.
+func (pc *PolicyControllerImpl) GetPolicy(c *gin.Context) {
+	response := pc.service.GetByID(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// CreatePolicy handles the request to add a new policy.
+func (pc *PolicyControllerImpl) CreatePolicy(c *gin.Context) {
+	response := pc.service.AddData(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// UpdatePolicy handles the request to update a policy.
+func (pc *PolicyControllerImpl) UpdatePolicy(c *gin.Context) {
+	response := pc.service.UpdateData(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// DeletePolicy handles the request to delete a policy.
+func (pc *PolicyControllerImpl) DeletePolicy(c *gin.Context) {
+	response := pc.service.DeleteData(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// DryRunPolicy handles the request to evaluate policies without calling the endpoint.
+func (pc *PolicyControllerImpl) DryRunPolicy(c *gin.Context) {
+	response := pc.service.DryRun(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
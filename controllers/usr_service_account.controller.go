@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ServiceAccountController interface {
+	GetAllServiceAccounts(c *gin.Context)
+	CreateServiceAccount(c *gin.Context)
+	DeleteServiceAccount(c *gin.Context)
+	GetAPIKeys(c *gin.Context)
+	CreateAPIKey(c *gin.Context)
+	RevokeAPIKey(c *gin.Context)
+}
+
+// ServiceAccountControllerImpl is the implementation of the ServiceAccountController interface.
+type ServiceAccountControllerImpl struct {
+	service service.ServiceAccountService
+}
+
+// ServiceAccountControllerConstructor creates a new instance of ServiceAccountControllerImpl.
+func ServiceAccountControllerConstructor(service service.ServiceAccountService) ServiceAccountController {
+	return &ServiceAccountControllerImpl{service: service}
+}
+
+// GetAllServiceAccounts handles the request to get all service accounts.
+func (sc *ServiceAccountControllerImpl) GetAllServiceAccounts(c *gin.Context) {
+	response := sc.service.GetAll(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// CreateServiceAccount handles the request to create a new service account.
+func (sc *ServiceAccountControllerImpl) CreateServiceAccount(c *gin.Context) {
+	response := sc.service.AddData(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// DeleteServiceAccount handles the request to delete a service account.
+func (sc *ServiceAccountControllerImpl) DeleteServiceAccount(c *gin.Context) {
+	response := sc.service.DeleteData(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// GetAPIKeys handles the request to get api keys of a service account.
+func (sc *ServiceAccountControllerImpl) GetAPIKeys(c *gin.Context) {
+	response := sc.service.GetAPIKeys(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// CreateAPIKey handles the request to create api key for a service account.
+func (sc *ServiceAccountControllerImpl) CreateAPIKey(c *gin.Context) {
+	response := sc.service.AddAPIKey(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// RevokeAPIKey handles the request to revoke api key of a service account.
+func (sc *ServiceAccountControllerImpl) RevokeAPIKey(c *gin.Context) {
+	response := sc.service.RevokeAPIKey(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionController interface {
+	GetMySessions(c *gin.Context)
+	RevokeMySession(c *gin.Context)
+	RevokeAllMySessions(c *gin.Context)
+	GetUserSessions(c *gin.Context)
+	RevokeUserSession(c *gin.Context)
+}
+
+// SessionControllerImpl is the implementation of the SessionController interface.
+type SessionControllerImpl struct {
+	service service.SessionService
+}
+
+// SessionControllerConstructor creates a new instance of SessionControllerImpl.
+func SessionControllerConstructor(service service.SessionService) SessionController {
+	return &SessionControllerImpl{service: service}
+}
+
+// GetMySessions handles the request to list active sessions of current user.
+func (sc *SessionControllerImpl) GetMySessions(c *gin.Context) {
+	response := sc.service.GetMine(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// RevokeMySession handles the request to sign out one session of current user.
+func (sc *SessionControllerImpl) RevokeMySession(c *gin.Context) {
+	response := sc.service.RevokeMine(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// RevokeAllMySessions handles the request to sign out every session of current user.
+func (sc *SessionControllerImpl) RevokeAllMySessions(c *gin.Context) {
+	response := sc.service.RevokeAllMine(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// GetUserSessions handles the request to list active sessions of a user by admin.
+func (sc *SessionControllerImpl) GetUserSessions(c *gin.Context) {
+	response := sc.service.GetByUser(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// RevokeUserSession handles the request to sign out one session of a user by admin.
+func (sc *SessionControllerImpl) RevokeUserSession(c *gin.Context) {
+	response := sc.service.RevokeByUser(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
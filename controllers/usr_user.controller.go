@@ -15,6 +15,10 @@ type USRUserController interface {
 	DeleteUser(c *gin.Context)
 	ChangePassUser(c *gin.Context)
 	ResetPassUser(c *gin.Context)
+	RevokeSessionsUser(c *gin.Context)
+	UnlockUser(c *gin.Context)
+	ApproveUser(c *gin.Context)
+	ImpersonateUser(c *gin.Context)
 }
 
 // UserControllerImpl is the implementation of the UserController interface.
@@ -68,3 +72,27 @@ func (uc *UserControllerImpl) ChangePassUser(c *gin.Context) {
 	response := uc.service.ChangePass(c)
 	handlers.ResponseFormatterWithLogging(c, response)
 }
+
+// RevokeSessionsUser handle the request to revoke every session of a user by admin
+func (uc *UserControllerImpl) RevokeSessionsUser(c *gin.Context) {
+	response := uc.service.RevokeSessions(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// UnlockUser handle the request to unlock user that locked by failed login attempts
+func (uc *UserControllerImpl) UnlockUser(c *gin.Context) {
+	response := uc.service.Unlock(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// ApproveUser handle the request to approve self-registered vendor
+func (uc *UserControllerImpl) ApproveUser(c *gin.Context) {
+	response := uc.service.Approve(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
+
+// ImpersonateUser handle the request of administrator to get token acting as another user
+func (uc *UserControllerImpl) ImpersonateUser(c *gin.Context) {
+	response := uc.service.Impersonate(c)
+	handlers.ResponseFormatterWithLogging(c, response)
+}
@@ -2,17 +2,70 @@ package database
 
 import (
 	"jxb-eprocurement/database/seed"
+	"jxb-eprocurement/helpers"
 	"jxb-eprocurement/models"
+	"log"
 
 	"gorm.io/gorm"
 )
 
 func Migrate(db *gorm.DB) {
 	db.AutoMigrate(&models.USR_Module{})
+	migrateFeatureCode(db)
 	db.AutoMigrate(&models.USR_Feature{})
 	db.AutoMigrate(&models.USR_Role{})
+	hasUserRoles := db.Migrator().HasTable("usr_userroles")
 	db.AutoMigrate(&models.USR_User{})
+	if !hasUserRoles {
+		migrateUserRoles(db)
+	}
+	db.AutoMigrate(&models.USR_Session{})
+	db.AutoMigrate(&models.USR_RefreshToken{})
+	db.AutoMigrate(&models.USR_RevokedToken{})
+	db.AutoMigrate(&models.USR_LoginThrottle{})
+	db.AutoMigrate(&models.USR_RecoveryCode{})
+	db.AutoMigrate(&models.USR_UserToken{})
+	db.AutoMigrate(&models.USR_PasswordHistory{})
+	db.AutoMigrate(&models.USR_APIKey{})
+	db.AutoMigrate(&models.USR_OIDCState{})
+	db.AutoMigrate(&models.USR_LoginHistory{})
+	db.AutoMigrate(&models.USR_Policy{})
 
 	// Seed initial data
 	seed.Seed(db)
 }
+
+// Feature created before feature code existed get code generated from its module and name,
+// the unique index is only created by AutoMigrate after every feature has its own code
+func migrateFeatureCode(db *gorm.DB) {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.USR_Feature{}) {
+		return
+	}
+	if !migrator.HasColumn(&models.USR_Feature{}, "Code") {
+		if err := migrator.AddColumn(&models.USR_Feature{}, "Code"); err != nil {
+			log.Fatalf("Error adding USR_Feature code column: %v", err)
+		}
+	}
+
+	var features []models.USR_Feature
+	db.Unscoped().Preload("Module", func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() }).
+		Where("code IS NULL OR code = ?", "").Order("id").Find(&features)
+
+	for _, feature := range features {
+		code, err := helpers.GenerateUniqueFeatureCode(db, feature.Module.Name, feature.Name)
+		if err != nil {
+			log.Fatalf("Error generating USR_Feature code: %v", err)
+		}
+		db.Unscoped().Model(&models.USR_Feature{}).Where("id = ?", feature.ID).Update("code", code)
+	}
+}
+
+// User created before multiple roles only has role_id, the role is copied into the user roles
+// join table when the table is first created
+func migrateUserRoles(db *gorm.DB) {
+	err := db.Exec("INSERT INTO usr_userroles (usr_user_id, usr_role_id) SELECT id, role_id FROM usr_users WHERE role_id <> 0").Error
+	if err != nil {
+		log.Fatalf("Error migrating USR_User roles: %v", err)
+	}
+}
@@ -5,264 +5,172 @@ import (
 	"jxb-eprocurement/models"
 	"log"
 
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-func Seed(db *gorm.DB) {
-	// Seed models.USR_Module
-	var modules []models.USR_Module
-	{
-		var moduleCount int64
-		db.Model(&models.USR_Module{}).Count(&moduleCount)
-		if moduleCount > 0 {
-			log.Println("models.USR_Modules already seeded.")
-			return
-		}
-
-		parentModules := []models.USR_Module{
-			{Name: "Access Management"},
-			{Name: "Vendor Management"},
-			{Name: "Plan Management"},
-			{Name: "Procurement Management"},
-			{Name: "Contract Management"},
-		}
-
-		// Creating data using transaction
-		err := db.Transaction(
-			func(tx *gorm.DB) error {
-				if err := tx.Create(&parentModules).Error; err != nil {
-					return err
-				}
+// Module tree with features of module that has no route yet, features required by routes come
+// from the route permission registry so they are not listed here
+type moduleSeed struct {
+	Name     string
+	Children []moduleSeed
+	Features []models.USR_Feature
+}
 
-				var subModules []models.USR_Module
+var moduleSeeds = []moduleSeed{
+	{Name: "Access Management", Children: []moduleSeed{
+		{Name: "Module"},
+		{Name: "Feature"},
+		{Name: "Role"},
+		{Name: "User"},
+		{Name: "Service Account"},
+		{Name: "Policy"},
+	}},
+	{Name: "Vendor Management", Children: []moduleSeed{
+		{Name: "Vendor", Features: []models.USR_Feature{
+			{Code: "vendor.view", Name: "View Vendor"},
+			{Code: "vendor.update", Name: "Update Vendor"},
+			{Code: "vendor.delete", Name: "Delete Vendor"},
+			{Code: "vendor.blacklist", Name: "Blacklist Vendor"},
+			{Code: "vendor.view_blacklisted", Name: "View Blacklisted Vendor"},
+		}},
+		{Name: "Vendor Profile", Features: []models.USR_Feature{
+			{Code: "vendor_profile.view", Name: "View Vendor Profile"},
+			{Code: "vendor_profile.update", Name: "Update Vendor Profile"},
+		}},
+	}},
+	{Name: "Plan Management", Children: []moduleSeed{
+		{Name: "RKA", Features: crudFeatures("rka", "RKA")},
+		{Name: "DRUPP", Features: crudFeatures("drupp", "DRUPP")},
+	}},
+	{Name: "Procurement Management", Children: []moduleSeed{
+		{Name: "Procurement", Features: append(crudFeatures("procurement", "Procurement"),
+			models.USR_Feature{Code: "procurement.announce", Name: "Announce Procurement"},
+			models.USR_Feature{Code: "procurement.choose_winner", Name: "Choose Winner Procurement"},
+		)},
+		{Name: "Interest", Features: crudFeatures("interest", "Interest")},
+		{Name: "Offer", Features: append(crudFeatures("offer", "Offer"),
+			models.USR_Feature{Code: "offer.validate", Name: "Validate Offer"},
+		)},
+		{Name: "Invoice", Features: crudFeatures("invoice", "Invoice")},
+		{Name: "Bill", Features: append(crudFeatures("bill", "Bill"),
+			models.USR_Feature{Code: "bill.pay", Name: "Pay Bill"},
+		)},
+		{Name: "Review", Features: crudFeatures("review", "Review")},
+	}},
+	{Name: "Contract Management", Children: []moduleSeed{
+		{Name: "Contract", Features: crudFeatures("contract", "Contract")},
+		{Name: "Contract Monitoring", Features: crudFeatures("contract_monitoring", "Contract Monitoring")},
+		{Name: "Addendum", Features: crudFeatures("addendum", "Addendum")},
+		{Name: "SPMK", Features: crudFeatures("spmk", "SPMK")},
+	}},
+}
 
-				for _, parent := range parentModules {
-					parentID := parent.ID // Ensure parent.ID is captured correctly in each iteration
-					switch parent.Name {
-					case "Access Management":
-						subModules = append(subModules,
-							models.USR_Module{Name: "Module", ParentID: &parentID},
-							models.USR_Module{Name: "Feature", ParentID: &parentID},
-							models.USR_Module{Name: "Role", ParentID: &parentID},
-							models.USR_Module{Name: "User", ParentID: &parentID},
-						)
-					case "Vendor Management":
-						subModules = append(subModules,
-							models.USR_Module{Name: "Vendor", ParentID: &parentID},
-							models.USR_Module{Name: "Vendor Profile", ParentID: &parentID},
-						)
-					case "Plan Management":
-						subModules = append(subModules,
-							models.USR_Module{Name: "RKA", ParentID: &parentID},
-							models.USR_Module{Name: "DRUPP", ParentID: &parentID},
-						)
-					case "Procurement Management":
-						subModules = append(subModules,
-							models.USR_Module{Name: "Procurement", ParentID: &parentID},
-							models.USR_Module{Name: "Interest", ParentID: &parentID},
-							models.USR_Module{Name: "Offer", ParentID: &parentID},
-							models.USR_Module{Name: "Invoice", ParentID: &parentID},
-							models.USR_Module{Name: "Bill", ParentID: &parentID},
-							models.USR_Module{Name: "Review", ParentID: &parentID},
-						)
-					case "Contract Management":
-						subModules = append(subModules,
-							models.USR_Module{Name: "Contract", ParentID: &parentID},
-							models.USR_Module{Name: "Contract Monitoring", ParentID: &parentID},
-							models.USR_Module{Name: "Addendum", ParentID: &parentID},
-							models.USR_Module{Name: "SPMK", ParentID: &parentID},
-						)
-					}
-				}
+// View, create, update and delete feature of a module
+func crudFeatures(prefix string, name string) []models.USR_Feature {
+	return []models.USR_Feature{
+		{Code: prefix + ".view", Name: "View " + name},
+		{Code: prefix + ".create", Name: "Create " + name},
+		{Code: prefix + ".update", Name: "Update " + name},
+		{Code: prefix + ".delete", Name: "Delete " + name},
+	}
+}
 
-				if err := tx.Create(&subModules).Error; err != nil {
-					return err
-				}
+// Create module (matched by name under the same parent) and feature (matched by code) that
+// doesn't exist yet, so new module and feature reach existing database too. Deleted one is
+// matched as well so it is not created again.
+func seedModules(tx *gorm.DB, seeds []moduleSeed, parentID *uint) error {
+	for _, seed := range seeds {
+		var module models.USR_Module
+		query := tx.Unscoped().Where("name = ?", seed.Name)
+		if parentID == nil {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", *parentID)
+		}
+		result := query.Limit(1).Find(&module)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			module = models.USR_Module{Name: seed.Name, ParentID: parentID}
+			if err := tx.Create(&module).Error; err != nil {
+				return err
+			}
+		}
 
-				modules = append(modules, parentModules...)
-				modules = append(modules, subModules...)
+		features := []models.USR_Feature{}
+		for _, feature := range seed.Features {
+			feature.ModuleID = module.ID
+			features = append(features, feature)
+		}
+		if err := seedFeatures(tx, features); err != nil {
+			return err
+		}
 
-				return nil
-			},
-		)
+		if err := seedModules(tx, seed.Children, &module.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		if err != nil {
-			log.Fatalf("Error seeding models.USR_Modules: %v", err)
-		} else {
-			log.Println("models.USR_Modules seeded successfully.")
+// Create feature whose code doesn't exist yet
+func seedFeatures(tx *gorm.DB, features []models.USR_Feature) error {
+	for _, feature := range features {
+		var count int64
+		if err := tx.Unscoped().Model(&models.USR_Feature{}).Where("code = ?", feature.Code).Count(&count).Error; err != nil {
+			return err
+		}
+		if count != 0 {
+			continue
+		}
+		if err := tx.Create(&feature).Error; err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	// Seed Features
-	var features []models.USR_Feature
-	{
-		var featureCount int64
-		db.Model(&models.USR_Feature{}).Count(&featureCount)
+// Create feature required by registered routes that doesn't exist yet, routes must be
+// registered before the seeder run
+func seedRouteFeatures(tx *gorm.DB) error {
+	codes := helpers.GetRouteFeatureCodes()
+	if len(codes) == 0 {
+		return nil
+	}
 
-		if featureCount == 0 {
+	var existing []string
+	if err := tx.Unscoped().Model(&models.USR_Feature{}).Where("code IN ?", codes).Pluck("code", &existing).Error; err != nil {
+		return err
+	}
+	existMap := map[string]struct{}{}
+	for _, code := range existing {
+		existMap[code] = struct{}{}
+	}
 
-			for _, module := range modules {
-				switch module.Name {
-				case "Module":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Module", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create Module", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Module", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Module", ModuleID: module.ID},
-					)
-				case "Feature":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Feature", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create Feature", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Feature", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Feature", ModuleID: module.ID},
-					)
-				case "Role":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Role", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create Role", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Role", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Role", ModuleID: module.ID},
-					)
-				case "User":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View User", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create User", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update User", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete User", ModuleID: module.ID},
-						models.USR_Feature{Name: "Change User Password", ModuleID: module.ID},
-						models.USR_Feature{Name: "Reset User Password", ModuleID: module.ID},
-					)
-				case "Vendor":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Vendor", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Vendor", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Vendor", ModuleID: module.ID},
-						models.USR_Feature{Name: "Validate Vendor", ModuleID: module.ID},
-						models.USR_Feature{Name: "Blacklist Vendor", ModuleID: module.ID},
-						models.USR_Feature{Name: "View Blacklisted Vendor", ModuleID: module.ID},
-					)
-				case "Vendor Profile":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Vendor Profile", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Vendor Profile", ModuleID: module.ID},
-					)
-				case "RKA":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View RKA", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create RKA", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update RKA", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete RKA", ModuleID: module.ID},
-					)
-				case "DRUPP":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View DRUPP", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create DRUPP", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update DRUPP", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete DRUPP", ModuleID: module.ID},
-					)
-				case "Procurement":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Procurement", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create Procurement", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Procurement", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Procurement", ModuleID: module.ID},
-						models.USR_Feature{Name: "Announce Procurement", ModuleID: module.ID},
-						models.USR_Feature{Name: "Choose Winner Procurement", ModuleID: module.ID},
-					)
-				case "Interest":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Interest", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create Interest", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Interest", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Interest", ModuleID: module.ID},
-					)
-				case "Offer":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Offer", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create Offer", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Offer", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Offer", ModuleID: module.ID},
-						models.USR_Feature{Name: "Validate Offer", ModuleID: module.ID},
-					)
-				case "Invoice":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Invoice", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create Invoice", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Invoice", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Invoice", ModuleID: module.ID},
-					)
-				case "Bill":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Bill", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create Bill", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Bill", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Bill", ModuleID: module.ID},
-						models.USR_Feature{Name: "Pay Bill", ModuleID: module.ID},
-					)
-				case "Review":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Review", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create Review", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Review", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Review", ModuleID: module.ID},
-					)
-				case "Contract":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Contract", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create Contract", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Contract", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Contract", ModuleID: module.ID},
-					)
-				case "Contract Monitoring":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Contract Monitoring", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create Contract Monitoring", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Contract Monitoring", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Contract Monitoring", ModuleID: module.ID},
-					)
-				case "Addendum":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View Addendum", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create Addendum", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update Addendum", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete Addendum", ModuleID: module.ID},
-					)
-				case "SPMK":
-					features = append(
-						features,
-						models.USR_Feature{Name: "View SPMK", ModuleID: module.ID},
-						models.USR_Feature{Name: "Create SPMK", ModuleID: module.ID},
-						models.USR_Feature{Name: "Update SPMK", ModuleID: module.ID},
-						models.USR_Feature{Name: "Delete SPMK", ModuleID: module.ID},
-					)
-				}
-			}
+	missing := []string{}
+	for _, code := range codes {
+		if _, exist := existMap[code]; !exist {
+			missing = append(missing, code)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
 
-			if err := db.Create(&features).Error; err != nil {
-				log.Fatalf("Error seeding USR_Feature: %v", err)
-			} else {
-				log.Println("USR_Feature seeded successfully.")
-			}
+	return helpers.CreateRouteFeatures(tx, missing)
+}
+
+func Seed(db *gorm.DB) {
+	// Seed models.USR_Module and models.USR_Feature, run on every startup
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := seedModules(tx, moduleSeeds, nil); err != nil {
+			return err
 		}
+		return seedRouteFeatures(tx)
+	})
+	if err != nil {
+		log.Fatalf("Error seeding models.USR_Modules: %v", err)
 	}
 
 	// Seed Roles
@@ -271,50 +179,56 @@ func Seed(db *gorm.DB) {
 		var roleCount int64
 		db.Model(&models.USR_Role{}).Count(&roleCount)
 
-		// Convert features to slice of pointers
-		var featurePtrs []*models.USR_Feature
-		for i := range features {
-			featurePtrs = append(featurePtrs, &features[i])
-		}
-
 		if roleCount == 0 {
+			var features []models.USR_Feature
+			if err := db.Find(&features).Error; err != nil {
+				log.Fatalf("Error seeding USR_Role: %v", err)
+			}
+
+			// Convert features to slice of pointers
+			var featurePtrs []*models.USR_Feature
+			for i := range features {
+				featurePtrs = append(featurePtrs, &features[i])
+			}
+
 			// Inritialize the map of feature for vendor
 			vendorFeature := map[string]struct{}{
-				"Update User":           {},
-				"Change User Password":  {},
-				"View Vendor Profile":   {},
-				"Update Vendor Profile": {},
-				"View Procurement":      {},
-				"View Interest":         {},
-				"Create Interest":       {},
-				"Update Interest":       {},
-				"Delete Interest":       {},
-				"View Offer":            {},
-				"Create Offer":          {},
-				"Update Offer":          {},
-				"Delete Offer":          {},
-				"View Contract":         {},
-				"View Addendum":         {},
-				"View SPMK":             {},
-				"View Invoice":          {},
-				"Create Invoice":        {},
-				"Update Invoice":        {},
-				"Delete Invoice":        {},
+				"user.update":           {},
+				"user.change_password":  {},
+				"vendor_profile.view":   {},
+				"vendor_profile.update": {},
+				"procurement.view":      {},
+				"interest.view":         {},
+				"interest.create":       {},
+				"interest.update":       {},
+				"interest.delete":       {},
+				"offer.view":            {},
+				"offer.create":          {},
+				"offer.update":          {},
+				"offer.delete":          {},
+				"contract.view":         {},
+				"addendum.view":         {},
+				"spmk.view":             {},
+				"invoice.view":          {},
+				"invoice.create":        {},
+				"invoice.update":        {},
+				"invoice.delete":        {},
 			}
 
 			// Role for vendor
 			vendorRole := models.USR_Role{
 				Name:             "Vendor",
 				IsAdministrative: false,
+				DataScope:        models.DataScopeOwn,
 				Features:         []*models.USR_Feature{},
 			}
 			for _, feature := range featurePtrs {
-				if _, exist := vendorFeature[feature.Name]; exist {
+				if _, exist := vendorFeature[feature.Code]; exist {
 					vendorRole.Features = append(vendorRole.Features, feature)
 				}
 			}
 
-			roles = append(roles, models.USR_Role{Name: "Admin", IsAdministrative: true, Features: featurePtrs})
+			roles = append(roles, models.USR_Role{Name: "Admin", IsAdministrative: true, DataScope: models.DataScopeAll, Features: featurePtrs})
 			roles = append(roles, vendorRole)
 
 			if err := db.Create(&roles).Error; err != nil {
@@ -335,16 +249,17 @@ func Seed(db *gorm.DB) {
 		for _, role := range roles {
 			if role.Name == "Admin" {
 				user.RoleID = role.ID
+				user.Roles = []*models.USR_Role{{ID: role.ID}}
 			}
 		}
 
 		// hashed env password
-		hashedPassword, hashErr := bcrypt.GenerateFromPassword([]byte(helpers.GetENVWithDefault("ADMIN_PASS", "|(-=-)|")), bcrypt.DefaultCost)
+		hashedPassword, hashErr := helpers.HashPassword(helpers.GetENVWithDefault("ADMIN_PASS", "|(-=-)|"))
 		if hashErr == nil {
-			user.Password = string(hashedPassword)
+			user.Password = hashedPassword
 
 			// Create User
-			if err := db.Create(&user).Error; err != nil {
+			if err := db.Omit("Roles.*").Create(&user).Error; err != nil {
 				log.Fatalf("Error seeding USR_User: %v", err)
 			} else {
 				log.Println("USR_User seeded successfully.")
@@ -1,15 +1,39 @@
 package dtos
 
-import "github.com/dgrijalva/jwt-go"
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
 
 type (
 	Claims struct {
-		UserID           uint     `json:"user_id"`
-		RoleID           uint     `json:"role_id"`
-		User             string   `json:"user"`
-		Role             string   `json:"role"`
-		IsAdministrative bool     `json:"is_administrative"`
-		Features         []string `json:"features"`
+		UserID           uint         `json:"user_id"`
+		RoleID           uint         `json:"role_id"`
+		RoleIDs          []uint       `json:"role_ids"`
+		User             string       `json:"user"`
+		Username         string       `json:"username"`
+		Role             string       `json:"role"`
+		Roles            []string     `json:"roles"`
+		IsAdministrative bool         `json:"is_administrative"`
+		Features         []string     `json:"features"`
+		SessionID        uint         `json:"sid"`
+		Act              *ActorClaims `json:"act,omitempty"`
+		jwt.StandardClaims
+	}
+
+	// ActorClaims is the real user behind impersonation token, the rest of the claims
+	// belong to the impersonated user
+	ActorClaims struct {
+		UserID   uint   `json:"user_id"`
+		Username string `json:"username"`
+	}
+
+	// ChallengeClaims is short-lived token given between login steps, e.g. waiting for
+	// two factor code. It has no session so it can't be used as access token.
+	ChallengeClaims struct {
+		UserID  uint   `json:"user_id"`
+		Purpose string `json:"purpose"`
 		jwt.StandardClaims
 	}
 
@@ -17,4 +41,129 @@ type (
 		UsernameOrEmail string `json:"username_or_email" form:"username_or_email" validate:"required,no_space"`
 		Password        string `json:"password" form:"password" validate:"required"`
 	}
+
+	InputRefreshTokenDTO struct {
+		RefreshToken string `json:"refresh_token" form:"refresh_token" validate:"required"`
+	}
+
+	InputRegisterDTO struct {
+		Username   string `json:"username" form:"username" validate:"required,no_space,min=3,max=100"`
+		Name       string `json:"name" form:"name" validate:"required,min=3,max=100"`
+		Email      string `json:"email" form:"email" validate:"required,email"`
+		Password   string `json:"password" form:"password" validate:"required"`
+		RePassword string `json:"re_password" form:"re_password" validate:"required"`
+	}
+
+	InputVerifyEmailDTO struct {
+		Token string `json:"token" form:"token" validate:"required"`
+	}
+
+	InputResendVerificationDTO struct {
+		Email string `json:"email" form:"email" validate:"required,email"`
+	}
+
+	InputForgotPasswordDTO struct {
+		Email string `json:"email" form:"email" validate:"required,email"`
+	}
+
+	InputResetPasswordDTO struct {
+		Token      string `json:"token" form:"token" validate:"required"`
+		Password   string `json:"password" form:"password" validate:"required"`
+		RePassword string `json:"re_password" form:"re_password" validate:"required"`
+	}
+
+	InputChangeExpiredPasswordDTO struct {
+		ChallengeToken string `json:"challenge_token" form:"challenge_token" validate:"required"`
+		Password       string `json:"password" form:"password" validate:"required"`
+		RePassword     string `json:"re_password" form:"re_password" validate:"required"`
+	}
+
+	// PasswordChangeChallengeDTO is returned by login when password already expired
+	PasswordChangeChallengeDTO struct {
+		PasswordChangeRequired bool      `json:"password_change_required"`
+		ChallengeToken         string    `json:"challenge_token"`
+		ExpiresAt              time.Time `json:"expires_at"`
+	}
+
+	InputOIDCCallbackDTO struct {
+		Code  string `json:"code" form:"code" validate:"required"`
+		State string `json:"state" form:"state" validate:"required"`
+	}
+
+	OIDCAuthorizationDTO struct {
+		AuthorizationURL string    `json:"authorization_url"`
+		ExpiresAt        time.Time `json:"expires_at"`
+	}
+
+	InputVerifyTwoFactorDTO struct {
+		ChallengeToken string `json:"challenge_token" form:"challenge_token" validate:"required"`
+		Code           string `json:"code" form:"code" validate:"required"`
+	}
+
+	InputTwoFactorCodeDTO struct {
+		Code string `json:"code" form:"code" validate:"required"`
+	}
+
+	// TwoFactorChallengeDTO is returned by login when second step is needed
+	TwoFactorChallengeDTO struct {
+		TwoFactorRequired  bool      `json:"two_factor_required"`
+		EnrollmentRequired bool      `json:"enrollment_required"`
+		ChallengeToken     string    `json:"challenge_token"`
+		ExpiresAt          time.Time `json:"expires_at"`
+	}
+
+	TwoFactorEnrollmentDTO struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+
+	// ProfileDTO is current user profile with live permission and the module tree pruned to
+	// modules the user can access, used by frontend to build navigation
+	ProfileDTO struct {
+		ID           uint                `json:"id"`
+		Username     string              `json:"username"`
+		Name         string              `json:"name"`
+		Email        string              `json:"email"`
+		Status       string              `json:"status"`
+		TOTPEnabled  bool                `json:"totp_enabled"`
+		Impersonator *ActorClaims        `json:"impersonator,omitempty"`
+		Role         USRRoleMinimalDTO   `json:"role"` // Primary role with effective administrative flag
+		Roles        []USRRoleMinimalDTO `json:"roles"`
+		Features     []string            `json:"features"`
+		Modules      []USRModuleDTO      `json:"modules"`
+	}
+
+	// InputPermissionCheckDTO is list of access checked for current user at once
+	InputPermissionCheckDTO struct {
+		Checks []InputPermissionCheckItemDTO `json:"checks" validate:"required,min=1,max=100,dive"`
+	}
+
+	// InputPermissionCheckItemDTO check access to a feature, or to a route when method and path
+	// are given (path as registered e.g. /api/v1/accesses/users/:id, or the real one e.g.
+	// /api/v1/accesses/users/12). ResourceID is the accessed record, taken from :id when omitted.
+	InputPermissionCheckItemDTO struct {
+		Feature    string `json:"feature" validate:"required_without=Path"`
+		ResourceID string `json:"resource_id"`
+		Method     string `json:"method" validate:"required_with=Path"`
+		Path       string `json:"path"`
+	}
+
+	// PermissionCheckDTO is decision of a single check, Features are the features allowing access
+	PermissionCheckDTO struct {
+		Feature    string   `json:"feature,omitempty"`
+		ResourceID string   `json:"resource_id,omitempty"`
+		Method     string   `json:"method,omitempty"`
+		Path       string   `json:"path,omitempty"`
+		Allowed    bool     `json:"allowed"`
+		Reason     string   `json:"reason"`
+		Features   []string `json:"features,omitempty"`
+	}
+
+	// TokenDTO is pair of access token and refresh token given to the client after login or refresh
+	TokenDTO struct {
+		Token            string    `json:"token"`
+		ExpiresAt        time.Time `json:"expires_at"`
+		RefreshToken     string    `json:"refresh_token"`
+		RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	}
 )
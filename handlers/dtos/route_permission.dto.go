@@ -0,0 +1,17 @@
+package dtos
+
+// RoutePermissionDTO is a route with the features required to access it.
+type RoutePermissionDTO struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Features    []string `json:"features"`
+	IsAdminOnly bool     `json:"is_admin_only"`
+}
+
+// RoutePermissionReportDTO list every protected route, features used by route that don't exist
+// and features that no route use.
+type RoutePermissionReportDTO struct {
+	Routes          []RoutePermissionDTO   `json:"routes"`
+	MissingFeatures []string               `json:"missing_features"`
+	UnusedFeatures  []USRFeatureMinimalDTO `json:"unused_features"`
+}
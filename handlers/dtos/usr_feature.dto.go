@@ -8,6 +8,7 @@ import (
 // It includes only the fields necessary for data transfer and serialization.
 type USRFeatureDTO struct {
 	ID       uint            `json:"id"`        // Unique identifier of the module
+	Code     string          `json:"code"`      // Immutable code used for authorization
 	Name     string          `json:"name"`      // Name of the module
 	ModuleID uint            `json:"parent_id"` // ID of the parent module, if any
 	Children []USRFeatureDTO `json:"children"`  // Child modules
@@ -17,12 +18,14 @@ type USRFeatureDTO struct {
 // It includes only the fields necessary for data transfer and serialization.
 type USRFeatureMinimalDTO struct {
 	ID       uint   `json:"id" form:"id"`                         // Unique identifier of the module
+	Code     string `json:"code" form:"code"`                     // Generated from module and name when empty
 	Name     string `json:"name" form:"name" validate:"required"` // Name of the module
 	ModuleID uint   `json:"module_id" form:"module_id" validate:"required"`
 }
 
 type USRFeatureWithModuleDTO struct {
 	ID     uint         `json:"id" form:"id"`                         // Unique identifier of the module
+	Code   string       `json:"code" form:"code"`                     // Immutable code used for authorization
 	Name   string       `json:"name" form:"name" validate:"required"` // Name of the module
 	Module USRModuleDTO `json:"module"`
 }
@@ -33,6 +36,7 @@ func ToUSRFeatureMinimalDTO(module models.USR_Feature) USRFeatureMinimalDTO {
 	// Return the DTO with converted fields
 	return USRFeatureMinimalDTO{
 		ID:       module.ID,
+		Code:     module.Code,
 		Name:     module.Name,
 		ModuleID: module.ModuleID,
 	}
@@ -57,6 +61,7 @@ func ToUSRFeatureMinimalWithModuleDTO(feature models.USR_Feature) USRFeatureWith
 	// Return the DTO with converted fields
 	return USRFeatureWithModuleDTO{
 		ID:     feature.ID,
+		Code:   feature.Code,
 		Name:   feature.Name,
 		Module: ToUSRModuleDTO(feature.Module),
 	}
@@ -85,6 +90,7 @@ func ToUSRFeatureMinimalModel(dto USRFeatureMinimalDTO) models.USR_Feature {
 	// Return the model with converted fields
 	return models.USR_Feature{
 		ID:       dto.ID,
+		Code:     dto.Code,
 		Name:     dto.Name,
 		ModuleID: dto.ModuleID,
 	}
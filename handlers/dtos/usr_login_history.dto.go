@@ -0,0 +1,44 @@
+package dtos
+
+import (
+	"jxb-eprocurement/models"
+	"time"
+)
+
+// USRLoginHistoryDTO represents a Data Transfer Object for the USR_LoginHistory model.
+type USRLoginHistoryDTO struct {
+	ID          uint      `json:"id"`
+	UserID      *uint     `json:"user_id"`
+	Username    string    `json:"username"`
+	Identifier  string    `json:"identifier"`
+	Method      string    `json:"method"`
+	Success     bool      `json:"success"`
+	Reason      string    `json:"reason"`
+	ClientIP    string    `json:"client_ip"`
+	UserAgent   string    `json:"user_agent"`
+	IsNewDevice bool      `json:"is_new_device"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ToUSRLoginHistoryDTOs converts slice of USR_LoginHistory model to slice of USRLoginHistoryDTO
+// in interface form, ready to be used for paginated result.
+func ToUSRLoginHistoryDTOs(histories []models.USR_LoginHistory) []interface{} {
+	historyDTOs := make([]interface{}, len(histories))
+	for i, history := range histories {
+		historyDTOs[i] = USRLoginHistoryDTO{
+			ID:          history.ID,
+			UserID:      history.UserID,
+			Username:    history.User.Username,
+			Identifier:  history.Identifier,
+			Method:      history.Method,
+			Success:     history.Success,
+			Reason:      history.Reason,
+			ClientIP:    history.ClientIP,
+			UserAgent:   history.UserAgent,
+			IsNewDevice: history.IsNewDevice,
+			CreatedAt:   history.CreatedAt,
+		}
+	}
+
+	return historyDTOs
+}
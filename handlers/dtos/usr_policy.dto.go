@@ -0,0 +1,97 @@
+package dtos
+
+import (
+	"jxb-eprocurement/models"
+	"time"
+)
+
+type (
+	// USRPolicyDTO represents a Data Transfer Object for the USR_Policy model.
+	USRPolicyDTO struct {
+		ID          uint      `json:"id"`
+		Name        string    `json:"name"`
+		Description string    `json:"description"`
+		FeatureCode string    `json:"feature_code"`
+		Effect      string    `json:"effect"`
+		Expression  string    `json:"expression"`
+		IsActive    bool      `json:"is_active"`
+		CreatedAt   time.Time `json:"created_at"`
+		UpdatedAt   time.Time `json:"updated_at"`
+	}
+
+	// DTO that serialization input from user for method POST and PUT
+	InputUSRPolicyDTO struct {
+		Name        string `json:"name" form:"name" validate:"required,max=100"`
+		Description string `json:"description" form:"description"`
+		FeatureCode string `json:"feature_code" form:"feature_code" validate:"required"`
+		Effect      string `json:"effect" form:"effect" validate:"required,oneof=allow deny"`
+		Expression  string `json:"expression" form:"expression" validate:"required"`
+		IsActive    *bool  `json:"is_active" form:"is_active"` // Default true
+	}
+
+	// InputPolicyDryRunDTO is the request to evaluate policies without calling the endpoint.
+	// Resource attributes are loaded by resource_id and overridden by resource, environment
+	// override the attributes of this request. Expression and effect test unsaved rule.
+	InputPolicyDryRunDTO struct {
+		UserID            uint                   `json:"user_id" form:"user_id" validate:"required"`
+		FeatureCode       string                 `json:"feature_code" form:"feature_code" validate:"required"`
+		OtherFeatureCodes []string               `json:"other_feature_codes" form:"other_feature_codes"` // Other features allowed by the route
+		ResourceID        string                 `json:"resource_id" form:"resource_id"`
+		Resource          map[string]interface{} `json:"resource"`
+		Environment       map[string]interface{} `json:"environment"`
+		Expression        string                 `json:"expression" form:"expression"`
+		Effect            string                 `json:"effect" form:"effect" validate:"omitempty,oneof=allow deny"`
+	}
+
+	// PolicyDryRunDTO is the decision of dry run with the attributes used and result of each policy
+	PolicyDryRunDTO struct {
+		Allowed    bool                   `json:"allowed"`
+		Reason     string                 `json:"reason"`
+		HasFeature bool                   `json:"has_feature"`
+		Features   []string               `json:"features"`
+		Attributes map[string]interface{} `json:"attributes"`
+		Policies   interface{}            `json:"policies"`
+	}
+)
+
+// ToUSRPolicyDTO converts a USR_Policy model to a USRPolicyDTO.
+func ToUSRPolicyDTO(policy models.USR_Policy) USRPolicyDTO {
+	return USRPolicyDTO{
+		ID:          policy.ID,
+		Name:        policy.Name,
+		Description: policy.Description,
+		FeatureCode: policy.FeatureCode,
+		Effect:      policy.Effect,
+		Expression:  policy.Expression,
+		IsActive:    policy.IsActive,
+		CreatedAt:   policy.CreatedAt,
+		UpdatedAt:   policy.UpdatedAt,
+	}
+}
+
+// ToUSRPolicyDTOs converts slice of USR_Policy model to slice of USRPolicyDTO in interface form,
+// ready to be used for paginated result.
+func ToUSRPolicyDTOs(policies []models.USR_Policy) []interface{} {
+	policyDTOs := make([]interface{}, len(policies))
+	for i, policy := range policies {
+		policyDTOs[i] = ToUSRPolicyDTO(policy)
+	}
+	return policyDTOs
+}
+
+// InputToUSRPolicyModel converts a serialization from InputUSRPolicyDTO to a USR_Policy model.
+func InputToUSRPolicyModel(dto InputUSRPolicyDTO) models.USR_Policy {
+	isActive := true
+	if dto.IsActive != nil {
+		isActive = *dto.IsActive
+	}
+
+	return models.USR_Policy{
+		Name:        dto.Name,
+		Description: dto.Description,
+		FeatureCode: dto.FeatureCode,
+		Effect:      dto.Effect,
+		Expression:  dto.Expression,
+		IsActive:    isActive,
+	}
+}
@@ -8,10 +8,36 @@ type (
 	// USRRoleDTO represents a Data Transfer Object for the USR_Role model in detail format.
 	// It includes only the fields necessary for data transfer and serialization.
 	USRRoleDTO struct {
-		ID               uint                       `json:"id" form:"id"`
-		Name             string                     `json:"name" form:"name"`
-		IsAdministrative bool                       `json:"is_administrative" form:"is_administrative"`
-		Modules          []USRModuleWithFeaturesDTO `json:"modules"`
+		ID               uint                            `json:"id" form:"id"`
+		Name             string                          `json:"name" form:"name"`
+		IsAdministrative bool                            `json:"is_administrative" form:"is_administrative"`
+		RequireTwoFactor bool                            `json:"require_two_factor" form:"require_two_factor"`
+		DataScope        string                          `json:"data_scope" form:"data_scope"`
+		ParentID         *uint                           `json:"parent_id" form:"parent_id"`
+		Modules          []USRModuleWithFeaturesDTO      `json:"modules"`           // Features granted directly to the role
+		GrantedModules   []USRModuleMinimalDTO           `json:"granted_modules"`   // Modules granted to the role, including its descendants
+		EffectiveModules []USRModuleWithFeatureGrantsDTO `json:"effective_modules"` // Every feature held by the role and how it is granted
+	}
+
+	// USRFeatureGrantDTO is feature held by role. Direct is true when the feature is granted to the
+	// role itself, otherwise it is implied by granted module or parent role named by granted_by.
+	USRFeatureGrantDTO struct {
+		ID          uint   `json:"id"`
+		ModuleID    uint   `json:"module_id"`
+		Code        string `json:"code"`
+		Name        string `json:"name"`
+		Direct      bool   `json:"direct"`
+		Source      string `json:"source"` // feature, module or parent_role
+		GrantedByID uint   `json:"granted_by_id,omitempty"`
+		GrantedBy   string `json:"granted_by,omitempty"`
+	}
+
+	// USRModuleWithFeatureGrantsDTO is module with features held by role
+	USRModuleWithFeatureGrantsDTO struct {
+		ID       uint                 `json:"id"`
+		Name     string               `json:"name"`
+		ParentID *uint                `json:"parent_id"`
+		Features []USRFeatureGrantDTO `json:"features"`
 	}
 
 	// USRRoleDTO represents a Data Transfer Object for the USR_Role model in minimal format.
@@ -20,21 +46,48 @@ type (
 		ID               uint   `json:"id" form:"id"`
 		Name             string `json:"name" form:"name" validate:"required"`
 		IsAdministrative bool   `json:"is_administrative"`
+		RequireTwoFactor bool   `json:"require_two_factor"`
+		DataScope        string `json:"data_scope"`
+		ParentID         *uint  `json:"parent_id"`
 	}
 
 	// DTO that serialization input from user for method POST and PUT
 	InputUSRRoleDTO struct {
 		Name             string `json:"name" form:"name" validate:"required"`
 		IsAdministrative bool   `json:"is_administrative" form:"is_administrative" validate:"boolean"`
-		Features         []uint `json:"features" form:"features" validate:"required"`
+		RequireTwoFactor bool   `json:"require_two_factor" form:"require_two_factor" validate:"boolean"`
+		DataScope        string `json:"data_scope" form:"data_scope" validate:"omitempty,oneof=own unit all"` // Default all
+		Features         []uint `json:"features" form:"features" validate:"required_without=Modules"`
+		Modules          []uint `json:"modules" form:"modules"`     // Every feature of the module and its descendants
+		ParentID         *uint  `json:"parent_id" form:"parent_id"` // Role whose features are inherited
 	}
 )
 
 // ToUSRRoleDTO converts a USR_Role model to a USRRoleDTO in detail format.
 // Use this function to where the module and feature that role have is needed.
 func ToUSRRoleDTO(role models.USR_Role) USRRoleDTO {
+	grantedModules := make([]USRModuleMinimalDTO, len(role.Modules))
+	for i, module := range role.Modules {
+		grantedModules[i] = ToUSRModuleMinimalDTO(*module)
+	}
+
+	return USRRoleDTO{
+		ID:               role.ID,
+		Name:             role.Name,
+		IsAdministrative: role.IsAdministrative,
+		RequireTwoFactor: role.RequireTwoFactor,
+		DataScope:        role.DataScope,
+		ParentID:         role.ParentID,
+		Modules:          ToUSRModulesWithFeaturesDTO(role.Features),
+		GrantedModules:   grantedModules,
+		EffectiveModules: []USRModuleWithFeatureGrantsDTO{},
+	}
+}
+
+// ToUSRModulesWithFeaturesDTO group features by their module, module of the features must be preloaded.
+func ToUSRModulesWithFeaturesDTO(features []*models.USR_Feature) []USRModuleWithFeaturesDTO {
 	moduleMap := make(map[uint]*USRModuleWithFeaturesDTO)
-	for _, feature := range role.Features {
+	for _, feature := range features {
 		module, exists := moduleMap[feature.Module.ID]
 		if !exists {
 			module = &USRModuleWithFeaturesDTO{
@@ -56,13 +109,7 @@ func ToUSRRoleDTO(role models.USR_Role) USRRoleDTO {
 	for _, module := range moduleMap {
 		modules = append(modules, *module)
 	}
-
-	return USRRoleDTO{
-		ID:               role.ID,
-		Name:             role.Name,
-		IsAdministrative: role.IsAdministrative,
-		Modules:          modules,
-	}
+	return modules
 }
 
 // ToUSRRoleModel converts a USRRoleDTO to a USR_Role model in detail format.
@@ -73,6 +120,9 @@ func ToUSRRoleModel(dto USRRoleDTO) models.USR_Role {
 		ID:               dto.ID,
 		Name:             dto.Name,
 		IsAdministrative: dto.IsAdministrative,
+		RequireTwoFactor: dto.RequireTwoFactor,
+		DataScope:        dto.DataScope,
+		ParentID:         dto.ParentID,
 	}
 }
 
@@ -83,6 +133,9 @@ func InputToUSRRoleModel(dto InputUSRRoleDTO) models.USR_Role {
 	return models.USR_Role{
 		Name:             dto.Name,
 		IsAdministrative: dto.IsAdministrative,
+		RequireTwoFactor: dto.RequireTwoFactor,
+		DataScope:        dto.DataScope,
+		ParentID:         dto.ParentID,
 	}
 }
 
@@ -94,6 +147,9 @@ func ToUSRRoleMinimalDTO(role models.USR_Role) USRRoleMinimalDTO {
 		ID:               role.ID,
 		Name:             role.Name,
 		IsAdministrative: role.IsAdministrative,
+		RequireTwoFactor: role.RequireTwoFactor,
+		DataScope:        role.DataScope,
+		ParentID:         role.ParentID,
 	}
 }
 
@@ -122,6 +178,9 @@ func ToUSRRoleMinimalModel(dto USRRoleMinimalDTO) models.USR_Role {
 		ID:               dto.ID,
 		Name:             dto.Name,
 		IsAdministrative: dto.IsAdministrative,
+		RequireTwoFactor: dto.RequireTwoFactor,
+		DataScope:        dto.DataScope,
+		ParentID:         dto.ParentID,
 	}
 }
 
@@ -0,0 +1,106 @@
+package dtos
+
+import (
+	"jxb-eprocurement/models"
+	"time"
+)
+
+type (
+	// USRServiceAccountDTO represents a Data Transfer Object for service account (USR_User model
+	// flagged as service account) in minimal format.
+	USRServiceAccountDTO struct {
+		ID        uint      `json:"id"`
+		Username  string    `json:"username"`
+		Name      string    `json:"name"`
+		Email     string    `json:"email"`
+		RoleID    uint      `json:"role_id"`
+		RoleName  string    `json:"role_name"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// USRAPIKeyDTO represents a Data Transfer Object for the USR_APIKey model, the key itself is never returned
+	USRAPIKeyDTO struct {
+		ID         uint                   `json:"id"`
+		Name       string                 `json:"name"`
+		Prefix     string                 `json:"prefix"`
+		Features   []USRFeatureMinimalDTO `json:"features"`
+		ExpiresAt  *time.Time             `json:"expires_at"`
+		LastUsedAt *time.Time             `json:"last_used_at"`
+		RevokedAt  *time.Time             `json:"revoked_at"`
+		CreatedAt  time.Time              `json:"created_at"`
+	}
+
+	// USRAPIKeyCreatedDTO is returned once when api key created, it is the only time the key is shown
+	USRAPIKeyCreatedDTO struct {
+		USRAPIKeyDTO
+		Key string `json:"key"`
+	}
+
+	// DTO that serialization input from user for creating service account
+	InputUSRServiceAccountDTO struct {
+		Username string `json:"username" form:"username" validate:"required,no_space,min=3,max=100"`
+		Name     string `json:"name" form:"name" validate:"required,min=3,max=100"`
+		Email    string `json:"email" form:"email" validate:"required,email"`
+		RoleID   uint   `json:"role_id" form:"role_id" validate:"required"`
+	}
+
+	// DTO that serialization input from user for creating api key, when expires_at empty
+	// the default lifetime is used
+	InputUSRAPIKeyDTO struct {
+		Name      string     `json:"name" form:"name" validate:"required,max=100"`
+		Features  []uint     `json:"features" form:"features" validate:"required,min=1"`
+		ExpiresAt *time.Time `json:"expires_at" form:"expires_at"`
+	}
+)
+
+// ToUSRServiceAccountDTO converts a USR_User model to a USRServiceAccountDTO.
+func ToUSRServiceAccountDTO(user models.USR_User) USRServiceAccountDTO {
+	return USRServiceAccountDTO{
+		ID:        user.ID,
+		Username:  user.Username,
+		Name:      user.Name,
+		Email:     user.Email,
+		RoleID:    user.RoleID,
+		RoleName:  user.Role.Name,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// ToUSRServiceAccountDTOs converts slice of USR_User model to slice of USRServiceAccountDTO.
+func ToUSRServiceAccountDTOs(users []models.USR_User) []USRServiceAccountDTO {
+	accountDTOs := []USRServiceAccountDTO{}
+	for _, user := range users {
+		accountDTOs = append(accountDTOs, ToUSRServiceAccountDTO(user))
+	}
+
+	return accountDTOs
+}
+
+// ToUSRAPIKeyDTO converts a USR_APIKey model to a USRAPIKeyDTO.
+func ToUSRAPIKeyDTO(apiKey models.USR_APIKey) USRAPIKeyDTO {
+	features := []USRFeatureMinimalDTO{}
+	for _, feature := range apiKey.Features {
+		features = append(features, ToUSRFeatureMinimalDTO(*feature))
+	}
+
+	return USRAPIKeyDTO{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Features:   features,
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		RevokedAt:  apiKey.RevokedAt,
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
+
+// ToUSRAPIKeyDTOs converts slice of USR_APIKey model to slice of USRAPIKeyDTO.
+func ToUSRAPIKeyDTOs(apiKeys []models.USR_APIKey) []USRAPIKeyDTO {
+	apiKeyDTOs := []USRAPIKeyDTO{}
+	for _, apiKey := range apiKeys {
+		apiKeyDTOs = append(apiKeyDTOs, ToUSRAPIKeyDTO(apiKey))
+	}
+
+	return apiKeyDTOs
+}
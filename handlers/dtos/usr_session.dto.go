@@ -0,0 +1,38 @@
+package dtos
+
+import (
+	"jxb-eprocurement/models"
+	"time"
+)
+
+// USRSessionDTO represents a Data Transfer Object for the USR_Session model.
+// Current is true for the session of the token used in the request.
+type USRSessionDTO struct {
+	ID             uint       `json:"id"`
+	UserAgent      string     `json:"user_agent"`
+	ClientIP       string     `json:"client_ip"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastActivityAt *time.Time `json:"last_activity_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	ImpersonatorID *uint      `json:"impersonator_id"`
+	Current        bool       `json:"current"`
+}
+
+// ToUSRSessionDTOs converts slice of USR_Session model to slice of USRSessionDTO.
+func ToUSRSessionDTOs(sessions []models.USR_Session, currentSessionID uint) []USRSessionDTO {
+	sessionDTOs := []USRSessionDTO{}
+	for _, session := range sessions {
+		sessionDTOs = append(sessionDTOs, USRSessionDTO{
+			ID:             session.ID,
+			UserAgent:      session.UserAgent,
+			ClientIP:       session.ClientIP,
+			CreatedAt:      session.CreatedAt,
+			LastActivityAt: session.LastActivityAt,
+			ExpiresAt:      session.ExpiresAt,
+			ImpersonatorID: session.ImpersonatorID,
+			Current:        session.ID == currentSessionID,
+		})
+	}
+
+	return sessionDTOs
+}
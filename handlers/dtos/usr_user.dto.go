@@ -3,76 +3,108 @@ package dtos
 import (
 	"jxb-eprocurement/models"
 	"strconv"
+	"time"
 )
 
 type (
 	// USRUserDTO represents a Data Transfer Object for the USR_User model in detail format.
 	// It includes only the fields necessary for data transfer and serialization.
 	USRUserDTO struct {
-		ID       uint       `json:"id"`               // Unique identifier of the user
-		Username string     `json:"username"`         // Name of the user
-		Name     string     `json:"name"`             // Name of the user
-		Email    string     `json:"email"`            // Name of the user
-		RoleID   uint       `json:"role_id" gorm:"-"` // Foreign Key To Role Table
-		Role     USRRoleDTO `json:"role"`             // Role Data
+		ID               uint         `json:"id"`                // Unique identifier of the user
+		Username         string       `json:"username"`          // Name of the user
+		Name             string       `json:"name"`              // Name of the user
+		Email            string       `json:"email"`             // Name of the user
+		RoleID           uint         `json:"role_id" gorm:"-"`  // Foreign Key To Role Table (primary role)
+		Role             USRRoleDTO   `json:"role"`              // Role Data
+		Roles            []USRRoleDTO `json:"roles"`             // Every role of the user, primary role first
+		OrganizationUnit string       `json:"organization_unit"` // Organization unit for data scope
+		Status           string       `json:"status"`            // Account status
 	}
 
 	// USRUserDTO represents a Data Transfer Object for the USR_User model in minimal format.
 	// It includes only the fields necessary for data transfer and serialization.
 	USRUserMinimalDTO struct {
-		ID       uint   `json:"id" form:"id"`                                 // Unique identifier of the user
-		Username string `json:"username" form:"username" validate:"required"` // Username of the user
-		Name     string `json:"name" form:"name" validate:"required"`         // Name of the user
-		Email    string `json:"email" form:"email" validate:"required"`       // Email of the user
-		RoleName string `json:"role_name"`
-		RoleID   uint   `json:"role_id" form:"role_id" validate:"required"`
+		ID               uint     `json:"id" form:"id"`                                 // Unique identifier of the user
+		Username         string   `json:"username" form:"username" validate:"required"` // Username of the user
+		Name             string   `json:"name" form:"name" validate:"required"`         // Name of the user
+		Email            string   `json:"email" form:"email" validate:"required"`       // Email of the user
+		RoleName         string   `json:"role_name"`
+		RoleID           uint     `json:"role_id" form:"role_id" validate:"required"`
+		RoleIDs          []uint   `json:"role_ids" form:"role_ids"`
+		RoleNames        []string `json:"role_names"`
+		OrganizationUnit string   `json:"organization_unit"`
+		Status           string   `json:"status"`
 	}
 
 	CreateUSRUserInputDTO struct {
-		ID       uint   `json:"id"`
-		Username string `json:"username" form:"username" validate:"required,no_space,min=3,max=100"`
-		Name     string `json:"name" form:"name" validate:"required,min=3,max=100"`
-		Email    string `json:"email" form:"email" validate:"required,email"`
-		Password string `json:"password" form:"password" validate:"required,min=6"`
-		RoleID   string `json:"role_id" form:"role_id" validate:"required,numeric"`
+		ID               uint   `json:"id"`
+		Username         string `json:"username" form:"username" validate:"required,no_space,min=3,max=100"`
+		Name             string `json:"name" form:"name" validate:"required,min=3,max=100"`
+		Email            string `json:"email" form:"email" validate:"required,email"`
+		Password         string `json:"password" form:"password" validate:"required"`
+		RoleID           string `json:"role_id" form:"role_id" validate:"omitempty,numeric"` // Primary role, first of role_ids when empty
+		RoleIDs          []uint `json:"role_ids" form:"role_ids"`
+		OrganizationUnit string `json:"organization_unit" form:"organization_unit" validate:"omitempty,max=100"`
 	}
 
 	UpdateUSRUserInputDTO struct {
-		ID       uint   `json:"id"`
-		Username string `json:"username" form:"username" validate:"required,no_space,min=3,max=100"`
-		Name     string `json:"name" form:"name" validate:"required,min=3,max=100"`
-		Email    string `json:"email" form:"email" validate:"required,email"`
-		RoleID   string `json:"role_id" form:"role_id" validate:"required,numeric"`
+		ID               uint   `json:"id"`
+		Username         string `json:"username" form:"username" validate:"required,no_space,min=3,max=100"`
+		Name             string `json:"name" form:"name" validate:"required,min=3,max=100"`
+		Email            string `json:"email" form:"email" validate:"required,email"`
+		RoleID           string `json:"role_id" form:"role_id" validate:"omitempty,numeric"` // Primary role, first of role_ids when empty
+		RoleIDs          []uint `json:"role_ids" form:"role_ids"`
+		OrganizationUnit string `json:"organization_unit" form:"organization_unit" validate:"omitempty,max=100"`
 	}
 
 	ResetPassUSRUserInputDTO struct {
-		Password   string `json:"password" form:"password" validate:"required,min=6"`
-		RePassword string `json:"re_password" form:"re_password" validate:"required,min=6"`
+		Password   string `json:"password" form:"password" validate:"required"`
+		RePassword string `json:"re_password" form:"re_password" validate:"required"`
 	}
 
 	ChangePassUSRUserInputDTO struct {
-		Password    string `json:"password" form:"password" validate:"required,min=6"`
-		RePassword  string `json:"re_password" form:"re_password" validate:"required,min=6"`
-		OldPassword string `json:"old_password" form:"old_password" validate:"required,min=6"`
+		Password    string `json:"password" form:"password" validate:"required"`
+		RePassword  string `json:"re_password" form:"re_password" validate:"required"`
+		OldPassword string `json:"old_password" form:"old_password" validate:"required"`
+	}
+
+	// ImpersonationDTO is access token acting as another user, it can't be refreshed
+	ImpersonationDTO struct {
+		Token     string            `json:"token"`
+		ExpiresAt time.Time         `json:"expires_at"`
+		User      USRUserMinimalDTO `json:"user"`
 	}
 
 	LogUserInfo struct {
-		ID       string `json:"id"`
-		Username string `json:"username"`
+		ID           string       `json:"id"`
+		Username     string       `json:"username"`
+		Impersonator *LogUserInfo `json:"impersonator,omitempty"`
 	}
 )
 
 // ToUSRUserDTO converts a USR_User model to a USRUserDTO in minimal format.
 // Use this function to where detail information of role not needed.
 func ToUSRUserMinimalDTO(user models.USR_User) USRUserMinimalDTO {
+	roles := user.EffectiveRoles()
+	roleIDs := make([]uint, len(roles))
+	roleNames := make([]string, len(roles))
+	for i, role := range roles {
+		roleIDs[i] = role.ID
+		roleNames[i] = role.Name
+	}
+
 	// Return the DTO with converted fields
 	return USRUserMinimalDTO{
-		ID:       user.ID,
-		Username: user.Username,
-		Name:     user.Name,
-		Email:    user.Email,
-		RoleID:   user.RoleID,
-		RoleName: user.Role.Name,
+		ID:               user.ID,
+		Username:         user.Username,
+		Name:             user.Name,
+		Email:            user.Email,
+		RoleID:           user.RoleID,
+		RoleName:         user.Role.Name,
+		RoleIDs:          roleIDs,
+		RoleNames:        roleNames,
+		OrganizationUnit: user.OrganizationUnit,
+		Status:           user.Status,
 	}
 }
 
@@ -82,9 +114,7 @@ func ToUSRUserMinimalDTOs(users []models.USR_User) []USRUserMinimalDTO {
 	var userDTOs []USRUserMinimalDTO
 
 	for _, user := range users {
-		// Assigning role name to user
 		userDTO := ToUSRUserMinimalDTO(user)
-		userDTO.RoleName = user.Role.Name
 
 		userDTOs = append(userDTOs, userDTO)
 	}
@@ -94,14 +124,22 @@ func ToUSRUserMinimalDTOs(users []models.USR_User) []USRUserMinimalDTO {
 }
 
 func ToUSRUserDTO(user models.USR_User) USRUserDTO {
+	roles := []USRRoleDTO{}
+	for _, role := range user.EffectiveRoles() {
+		roles = append(roles, ToUSRRoleDTO(*role))
+	}
+
 	// Return the DTO with converted fields
 	return USRUserDTO{
-		ID:       user.ID,
-		Username: user.Username,
-		Name:     user.Name,
-		Email:    user.Email,
-		RoleID:   user.RoleID,
-		Role:     ToUSRRoleDTO(user.Role),
+		ID:               user.ID,
+		Username:         user.Username,
+		Name:             user.Name,
+		Email:            user.Email,
+		RoleID:           user.RoleID,
+		Role:             ToUSRRoleDTO(user.Role),
+		Roles:            roles,
+		OrganizationUnit: user.OrganizationUnit,
+		Status:           user.Status,
 	}
 }
 
@@ -119,32 +157,61 @@ func ToUSRUserMinimalModel(dto USRUserMinimalDTO) models.USR_User {
 // InputToUSRUserModel converts a serialization from InputUSRUserDTO to a USR_User model in detail format.
 // Use this function to where the feature that role have is needed.
 func InputCreateToUSRUserModel(dto CreateUSRUserInputDTO) models.USR_User {
-	// convert string into uint
-	roleId, _ := strconv.ParseUint(dto.RoleID, 10, 32)
+	roleID, roles := inputToUSRUserRoles(dto.RoleID, dto.RoleIDs)
 
 	// Return the model with converted fields
 	return models.USR_User{
-		Username: dto.Username,
-		Name:     dto.Name,
-		Email:    dto.Email,
-		Password: dto.Password,
-		RoleID:   uint(roleId),
+		Username:         dto.Username,
+		Name:             dto.Name,
+		Email:            dto.Email,
+		Password:         dto.Password,
+		RoleID:           roleID,
+		Roles:            roles,
+		OrganizationUnit: dto.OrganizationUnit,
 	}
 }
 
 // InputToUSRUserModel converts a serialization from InputUSRUserDTO to a USR_User model in detail format.
 // Use this function to where the feature that role have is needed.
 func InputUpdateToUSRUserModel(dto UpdateUSRUserInputDTO) models.USR_User {
-	// convert string into uint
-	roleId, _ := strconv.ParseUint(dto.RoleID, 10, 32)
+	roleID, roles := inputToUSRUserRoles(dto.RoleID, dto.RoleIDs)
 
 	// Return the model with converted fields
 	return models.USR_User{
-		Username: dto.Username,
-		Name:     dto.Name,
-		Email:    dto.Email,
-		RoleID:   uint(roleId),
+		Username:         dto.Username,
+		Name:             dto.Name,
+		Email:            dto.Email,
+		RoleID:           roleID,
+		Roles:            roles,
+		OrganizationUnit: dto.OrganizationUnit,
+	}
+}
+
+// Combine primary role and role list of user input into distinct roles, primary role first.
+// Primary role is the first of role list when not given.
+func inputToUSRUserRoles(roleID string, roleIDs []uint) (uint, []*models.USR_Role) {
+	// convert string into uint
+	primaryID, _ := strconv.ParseUint(roleID, 10, 32)
+
+	ids := roleIDs
+	if primaryID != 0 {
+		ids = append([]uint{uint(primaryID)}, roleIDs...)
+	}
+
+	roles := []*models.USR_Role{}
+	seen := map[uint]struct{}{}
+	for _, id := range ids {
+		if _, exist := seen[id]; exist {
+			continue
+		}
+		seen[id] = struct{}{}
+		roles = append(roles, &models.USR_Role{ID: id})
+	}
+
+	if len(roles) == 0 {
+		return 0, roles
 	}
+	return roles[0].ID, roles
 }
 
 // Function to convert slice of USRUserMinimalDTO into slice of interface
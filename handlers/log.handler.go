@@ -20,8 +20,39 @@ import (
 var (
 	apiLogger    *zap.Logger
 	systemLogger *zap.Logger
+
+	// Fields that never written as plain value into api log
+	sensitiveFields = []string{"key", "token", "refresh_token", "password", "re_password", "old_password", "challenge_token"}
+
+	// Fields that are only sensitive on some routes, keyed by path segment of the route. "code" is
+	// TOTP or recovery code on two factor route and authorization code on OIDC route, but it is
+	// feature code on other routes that must stay readable in audit log.
+	routeSensitiveFields = map[string][]string{
+		"/auth/2fa/":  {"code", "secret", "otpauth_uri"},
+		"/auth/oidc/": {"code", "state"},
+	}
 )
 
+// Get fields redacted from log of the request path
+func sensitiveFieldsFor(path string) []string {
+	fields := sensitiveFields
+	for segment, routeFields := range routeSensitiveFields {
+		if strings.Contains(path, segment) {
+			fields = append(append([]string{}, fields...), routeFields...)
+		}
+	}
+	return fields
+}
+
+func isSensitiveField(key string, fields []string) bool {
+	for _, field := range fields {
+		if key == field {
+			return true
+		}
+	}
+	return false
+}
+
 func InitLogger() {
 	// Create logs directory if it does not exist
 	if err := os.MkdirAll("logs", os.ModePerm); err != nil {
@@ -70,6 +101,8 @@ func APILogger() gin.HandlerFunc {
 			identifier = c.GetString("X-Request-ID")
 		)
 
+		fields := sensitiveFieldsFor(c.Request.URL.Path)
+
 		// Read the request body
 		var requestBody interface{}
 		contentType := c.Request.Header.Get("Content-Type")
@@ -80,7 +113,7 @@ func APILogger() gin.HandlerFunc {
 			} else {
 				multipartData := make(map[string]interface{})
 				for key, values := range c.Request.MultipartForm.Value {
-					if key == "password" || key == "re_password" || key == "old_password" {
+					if isSensitiveField(key, fields) {
 						multipartData[key] = "[REDACTED]"
 					} else {
 						if len(values) > 1 {
@@ -101,7 +134,7 @@ func APILogger() gin.HandlerFunc {
 				} else {
 					jsonFormData := make(map[string]interface{})
 					for key, values := range formData {
-						if key == "password" || key == "re_password" || key == "old_password" {
+						if isSensitiveField(key, fields) {
 							jsonFormData[key] = "[REDACTED]"
 						} else {
 							if len(values) > 1 {
@@ -117,6 +150,8 @@ func APILogger() gin.HandlerFunc {
 				err := json.Unmarshal(bodyBytes, &requestBody)
 				if err != nil {
 					requestBody = string(bodyBytes)
+				} else if jsonBody, ok := requestBody.(map[string]interface{}); ok {
+					redactFields(jsonBody, fields)
 				}
 			}
 			// Restore the request body for downstream handlers
@@ -145,6 +180,13 @@ func APILogger() gin.HandlerFunc {
 			httpMethod   = c.Request.Method
 		)
 
+		// OIDC callback receive code and state in query
+		for key := range queryParams {
+			if isSensitiveField(key, fields) {
+				queryParams[key] = []string{"[REDACTED]"}
+			}
+		}
+
 		var jsonResponseBody map[string]interface{}
 		if err := json.Unmarshal(responseBody, &jsonResponseBody); err != nil {
 			// If the response is not JSON, log it as a string
@@ -152,7 +194,7 @@ func APILogger() gin.HandlerFunc {
 				"raw": string(responseBody),
 			}
 		} else {
-			redactFields(jsonResponseBody, []string{"key", "token", "password", "re_password", "old_password"})
+			redactFields(jsonResponseBody, fields)
 		}
 
 		// Extract message from JSON response body
@@ -163,11 +205,12 @@ func APILogger() gin.HandlerFunc {
 			message = "API LOG | No message in response"
 		}
 
-		// Get username from session
+		// Get username from session, impersonator is the real user behind impersonation token
 		var username string
 		if sessionUsername, ok := c.Get("username"); ok {
 			username = sessionUsername.(string)
 		}
+		impersonator := c.GetString("impersonator_username")
 
 		var logFunc func(string, ...zapcore.Field)
 		switch {
@@ -194,6 +237,7 @@ func APILogger() gin.HandlerFunc {
 			zap.String("user_agent", userAgent),
 			zap.String("client_ip", clientIP),
 			zap.String("username", username),
+			zap.String("impersonator", impersonator),
 			zap.Time("start_time", startTime),
 			zap.Time("end_time", endTime),
 		)
@@ -240,6 +284,8 @@ func LogSystem(logData LogSystemParam) {
 
 	if logData.UserInfo.ID == "" && logData.UserInfo.Username == "" {
 		userInfo = struct{}{}
+	} else {
+		userInfo = logData.UserInfo
 	}
 
 	systemLogger.Info("System Log",
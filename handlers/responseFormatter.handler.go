@@ -105,15 +105,7 @@ func ResponseFormatterWithLogging(c *gin.Context, responseLogging ServiceRespons
 	}
 
 	if !responseLogging.Log.StartTime.IsZero() {
-		// Get UserID from session
-		if sessionUserID, ok := c.Get("UserID"); ok {
-			userLog.ID = sessionUserID.(string)
-		}
-
-		// Get username from session
-		if sessionUsername, ok := c.Get("username"); ok {
-			userLog.Username = sessionUsername.(string)
-		}
+		userLog = getLogUserInfo(c)
 
 		// Check if struct empty
 		// fmt.Println(responseLogging.Log)
@@ -137,6 +129,25 @@ func ResponseFormatterWithLogging(c *gin.Context, responseLogging ServiceRespons
 }
 
 func WriteLog(c *gin.Context, status int, message string, err interface{}, log Log) {
+	userLog := getLogUserInfo(c)
+
+	logSystemParam := LogSystemParam{
+		Identifier: c.GetString("X-Request-ID"),
+		StatusCode: status,
+		Location:   log.Location,
+		Message:    message,
+		StartTime:  log.StartTime,
+		EndTime:    time.Now(),
+		UserInfo:   userLog,
+		Err:        err,
+	}
+
+	LogSystem(logSystemParam)
+}
+
+// Get identity of the requester from session, the real user is included when the request
+// is made using impersonation token
+func getLogUserInfo(c *gin.Context) dtos.LogUserInfo {
 	userLog := dtos.LogUserInfo{}
 
 	// Get UserID from session
@@ -149,16 +160,13 @@ func WriteLog(c *gin.Context, status int, message string, err interface{}, log L
 		userLog.Username = sessionUsername.(string)
 	}
 
-	logSystemParam := LogSystemParam{
-		Identifier: c.GetString("X-Request-ID"),
-		StatusCode: status,
-		Location:   log.Location,
-		Message:    message,
-		StartTime:  log.StartTime,
-		EndTime:    time.Now(),
-		UserInfo:   userLog,
-		Err:        err,
+	// Get impersonator from session
+	if impersonatorID, ok := c.Get("impersonator_id"); ok {
+		userLog.Impersonator = &dtos.LogUserInfo{
+			ID:       impersonatorID.(string),
+			Username: c.GetString("impersonator_username"),
+		}
 	}
 
-	LogSystem(logSystemParam)
+	return userLog
 }
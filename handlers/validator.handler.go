@@ -31,8 +31,16 @@ func customMessage(tag string, size string) string {
 		return "Invalid email format"
 	case "min":
 		return fmt.Sprintf("Field require minimum of %s size/length/unit", size)
+	case "max":
+		return fmt.Sprintf("Field require maximum of %s size/length/unit", size)
+	case "required_with":
+		return fmt.Sprintf("Field is required when %s is filled", size)
 	case "no_space":
 		return "Field should not contain spaces"
+	case "required_without":
+		return fmt.Sprintf("Field is required when %s is empty", size)
+	case "oneof":
+		return fmt.Sprintf("Field must be one of: %s", size)
 	}
 	return "Invalid Field"
 }
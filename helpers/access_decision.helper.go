@@ -0,0 +1,79 @@
+package helpers
+
+import (
+	"jxb-eprocurement/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AccessRequest is access to a route being decided. Method and Path of the route are used as
+// policy environment, ResourceID is the id of the accessed record (e.g. :id param).
+type AccessRequest struct {
+	AllowedFeatures []string
+	IsAdminOnly     bool
+	ResourceID      string
+	Method          string
+	Path            string
+}
+
+// AccessDecision is result of deciding access, Reason explain denied access
+type AccessDecision struct {
+	Allowed   bool
+	Reason    string
+	Granted   []string // Allowed features held by the user
+	Features  []string // Granted features whose policies passed
+	DataScope DataScope
+	Policies  []PolicyResult
+}
+
+// Reason of denied access that doesn't reveal which check failed to the caller
+const accessDeniedReason = "Unauthorized to access this resource"
+
+// RequestFeatures return features of the user usable by current request, api key can only use
+// the features granted to the key
+func RequestFeatures(c *gin.Context, permission UserPermission) []string {
+	features := permission.Features
+	if keyFeatures, exist := c.Get("api_key_features"); exist {
+		keyFeatures, _ := keyFeatures.([]string)
+		features = IntersectFeatures(features, keyFeatures)
+	}
+	return features
+}
+
+// DecideAccess decide whether the user can access the route, shared by Authorization middleware
+// and permission check endpoint so both always agree. User must be active, administrative for
+// admin only route, hold one of allowed features and pass every policy of one of those features.
+func DecideAccess(db *gorm.DB, c *gin.Context, permission UserPermission, features []string, request AccessRequest) (AccessDecision, error) {
+	if permission.Status != models.UserStatusActive {
+		return AccessDecision{Reason: "User account is not active"}, nil
+	}
+
+	// Only admin can use this resource
+	if request.IsAdminOnly && !permission.IsAdministrative {
+		return AccessDecision{Reason: accessDeniedReason}, nil
+	}
+
+	// Check if user have allowed list
+	granted := IntersectFeatures(features, request.AllowedFeatures)
+	if len(granted) == 0 {
+		return AccessDecision{Reason: accessDeniedReason}, nil
+	}
+
+	// Attribute based policies are checked per granted feature, access is allowed when one of
+	// the features pass all of its policies
+	policyDecision, err := AuthorizePolicies(db, c, permission, granted, request)
+	if err != nil {
+		return AccessDecision{}, err
+	}
+
+	// Rows the user can access is decided by the roles that grant the features allowing access
+	return AccessDecision{
+		Allowed:   policyDecision.Allowed,
+		Reason:    policyDecision.Reason,
+		Granted:   granted,
+		Features:  policyDecision.Features,
+		DataScope: ResolveDataScope(permission, policyDecision.Features),
+		Policies:  policyDecision.Policies,
+	}, nil
+}
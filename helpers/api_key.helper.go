@@ -0,0 +1,69 @@
+package helpers
+
+import (
+	"errors"
+	"jxb-eprocurement/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Prefix of every api key, make leaked key easy to recognize by secret scanner
+const apiKeyPrefix = "eproc_"
+
+// How often last used time of api key is written, avoid update on every request
+const apiKeyUsageInterval = time.Minute
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// Generate new api key, returned prefix is stored to help user recognize the key later
+func GenerateAPIKey() (string, string, error) {
+	token, err := GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	key := apiKeyPrefix + token
+	return key, key[:len(apiKeyPrefix)+6], nil
+}
+
+// AuthenticateAPIKey find the active api key of a service account and record its usage
+func AuthenticateAPIKey(db *gorm.DB, key string) (models.USR_APIKey, error) {
+	var apiKey models.USR_APIKey
+	result := db.Preload("Features").Preload("User").Limit(1).Where("key_hash = ?", HashToken(key)).Find(&apiKey)
+	if result.Error != nil {
+		return apiKey, result.Error
+	}
+
+	now := time.Now()
+	if result.RowsAffected == 0 ||
+		apiKey.RevokedAt != nil ||
+		(apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(now)) ||
+		apiKey.User.ID == 0 ||
+		!apiKey.User.IsServiceAccount {
+		return apiKey, ErrInvalidAPIKey
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyUsageInterval {
+		db.Model(&models.USR_APIKey{}).Where("id = ?", apiKey.ID).UpdateColumn("last_used_at", now)
+	}
+
+	return apiKey, nil
+}
+
+// Return features that exist in both list, used to limit role features to api key features
+func IntersectFeatures(features []string, allowedFeatures []string) []string {
+	allowedFeaturesMap := make(map[string]struct{}, len(allowedFeatures))
+	for _, feature := range allowedFeatures {
+		allowedFeaturesMap[feature] = struct{}{}
+	}
+
+	intersection := []string{}
+	for _, feature := range features {
+		if _, exists := allowedFeaturesMap[feature]; exists {
+			intersection = append(intersection, feature)
+		}
+	}
+
+	return intersection
+}
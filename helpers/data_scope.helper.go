@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"jxb-eprocurement/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DataScope is the rows a user can access on current request, resolved by Authorization from
+// the roles that grant the feature of the route
+type DataScope struct {
+	Level            string
+	UserID           uint
+	OrganizationUnit string
+}
+
+var dataScopeRank = map[string]int{
+	models.DataScopeOwn:  1,
+	models.DataScopeUnit: 2,
+	models.DataScopeAll:  3,
+}
+
+func IsValidDataScope(level string) bool {
+	_, exist := dataScopeRank[level]
+	return exist
+}
+
+// Return the broader of two scope, role created before data scope existed can access every row
+func broaderDataScope(a string, b string) string {
+	if !IsValidDataScope(b) {
+		b = models.DataScopeAll
+	}
+	if dataScopeRank[b] > dataScopeRank[a] {
+		return b
+	}
+	return a
+}
+
+// Broadest scope of each feature code among the roles of the user that grant the feature
+func userFeatureScopes(user models.USR_User) map[string]string {
+	scopes := map[string]string{}
+	for _, role := range user.EffectiveRoles() {
+		for _, feature := range role.Features {
+			scopes[feature.Code] = broaderDataScope(scopes[feature.Code], role.DataScope)
+		}
+	}
+	return scopes
+}
+
+// ResolveDataScope get the broadest scope of the given features that the user hold, user that
+// hold none of them only get own scope
+func ResolveDataScope(permission UserPermission, features []string) DataScope {
+	scope := DataScope{Level: models.DataScopeOwn, UserID: permission.UserID, OrganizationUnit: permission.OrganizationUnit}
+	for _, feature := range features {
+		if level, exist := permission.FeatureScopes[feature]; exist {
+			scope.Level = broaderDataScope(scope.Level, level)
+		}
+	}
+	return scope
+}
+
+// Get data scope of current request set by Authorization, request without it only get own scope
+func GetDataScope(c *gin.Context) DataScope {
+	if scope, exist := c.Get("data_scope"); exist {
+		if scope, ok := scope.(DataScope); ok {
+			return scope
+		}
+	}
+
+	var user models.USR_User
+	GetUserPayload(c, &user)
+	return DataScope{Level: models.DataScopeOwn, UserID: user.ID}
+}
+
+// ScopeData limit query to the rows allowed by data scope, ownerColumn is the column holding id
+// of the user who own the row (e.g. "id" for user, "user_id" for other resource). Unit scope
+// match owner within the same organization unit, user without unit fall back to own scope.
+func ScopeData(scope DataScope, ownerColumn string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		switch {
+		case scope.Level == models.DataScopeAll:
+			return db
+		case scope.Level == models.DataScopeUnit && scope.OrganizationUnit != "":
+			units := db.Session(&gorm.Session{NewDB: true}).Model(&models.USR_User{}).
+				Select("id").Where("organization_unit = ?", scope.OrganizationUnit)
+			return db.Where(ownerColumn+" IN (?)", units)
+		}
+		return db.Where(ownerColumn+" = ?", scope.UserID)
+	}
+}
@@ -1,6 +1,9 @@
 package helpers
 
-import "os"
+import (
+	"os"
+	"strconv"
+)
 
 // Function to abstarct the process of getting env data that also set default value
 // if the env attribute empty or doesn't exist
@@ -13,3 +16,25 @@ func GetENVWithDefault(key string, defaultValue string) string {
 
 	return value
 }
+
+// Function to get env data as integer, default value is used when the env attribute
+// empty, doesn't exist or not a valid integer
+func GetENVIntWithDefault(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// Function to get env data as boolean, default value is used when the env attribute
+// empty, doesn't exist or not a valid boolean
+func GetENVBoolWithDefault(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,77 @@
+package helpers
+
+import (
+	"fmt"
+	"jxb-eprocurement/models"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Feature code is "<module>.<action>" in lower snake case, e.g. "user.view" or "user.reset_password"
+var featureCodePattern = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*(\.[a-z0-9]+(_[a-z0-9]+)*)+$`)
+
+func IsValidFeatureCode(code string) bool {
+	return len(code) <= 100 && featureCodePattern.MatchString(code)
+}
+
+// Split text into lower case words, anything other than ascii letter and digit separate words
+func codeWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9')
+	})
+}
+
+// GenerateFeatureCode derive feature code from module and feature name, the module words are
+// removed from feature name to get the action (e.g. "User" + "Reset User Password" give
+// "user.reset_password"). Used for feature created without code and for existing data migration.
+func GenerateFeatureCode(moduleName string, featureName string) string {
+	module := codeWords(moduleName)
+	words := codeWords(featureName)
+
+	action := words
+	for i := 0; len(module) != 0 && i+len(module) <= len(words); i++ {
+		if strings.Join(words[i:i+len(module)], " ") == strings.Join(module, " ") {
+			action = append(append([]string{}, words[:i]...), words[i+len(module):]...)
+			break
+		}
+	}
+	if len(action) == 0 {
+		action = words
+	}
+	if len(module) == 0 {
+		module = []string{"feature"}
+	}
+	if len(action) == 0 {
+		action = []string{"access"}
+	}
+
+	return strings.Join(module, "_") + "." + strings.Join(action, "_")
+}
+
+// GenerateUniqueFeatureCode generate feature code that isn't used yet (deleted feature included),
+// number suffix is added on collision
+func GenerateUniqueFeatureCode(db *gorm.DB, moduleName string, featureName string) (string, error) {
+	base := GenerateFeatureCode(moduleName, featureName)
+	if len(base) > 95 {
+		base = strings.TrimRight(base[:95], "_.")
+	}
+
+	for i := 1; i <= 100; i++ {
+		code := base
+		if i != 1 {
+			code = fmt.Sprintf("%s_%d", base, i)
+		}
+
+		var count int64
+		if err := db.Model(&models.USR_Feature{}).Unscoped().Where("code = ?", code).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+
+	return "", fmt.Errorf("unable to find available feature code for %s", base)
+}
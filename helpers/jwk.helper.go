@@ -0,0 +1,260 @@
+package helpers
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// jwtKey is one key inside the key ring, retired key only have public key
+// and only used to verify token issued before rotation
+type jwtKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey interface{}
+	PublicKey  interface{}
+}
+
+// jwtKeyRing hold every key used to sign and verify jwt. When JWT_KEYS_DIR is not set
+// the key ring fall back to HS256 using JWT_SECRET.
+type jwtKeyRing struct {
+	signingKey *jwtKey
+	keys       map[string]*jwtKey
+	hmacSecret []byte
+}
+
+// JWK is public key representation used in JWKS endpoint (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+var (
+	keyRing      *jwtKeyRing
+	keyRingMutex sync.RWMutex
+)
+
+// LoadJWTKeys (re)load signing and verification keys. Every "<kid>.pem" file inside JWT_KEYS_DIR
+// is private key (RSA or Ed25519) and every "<kid>.pub.pem" is public key of retired key.
+// Key used for signing is JWT_SIGNING_KEY_ID, or the last private key ordered by kid.
+func LoadJWTKeys() error {
+	ring := &jwtKeyRing{keys: map[string]*jwtKey{}}
+
+	keysDir := os.Getenv("JWT_KEYS_DIR")
+	if keysDir == "" {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			return errors.New("either JWT_KEYS_DIR or JWT_SECRET must be set")
+		}
+		ring.hmacSecret = []byte(secret)
+	} else {
+		files, err := filepath.Glob(filepath.Join(keysDir, "*.pem"))
+		if err != nil {
+			return err
+		}
+
+		var privateKeyIDs []string
+		for _, file := range files {
+			kid := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(file), ".pem"), ".pub")
+
+			key, err := readJWTKey(file, kid)
+			if err != nil {
+				return err
+			}
+
+			// Private key always win over public only key with the same kid
+			if existing, exist := ring.keys[kid]; exist && existing.PrivateKey != nil {
+				continue
+			}
+			ring.keys[kid] = key
+
+			if key.PrivateKey != nil {
+				privateKeyIDs = append(privateKeyIDs, kid)
+			}
+		}
+
+		if len(privateKeyIDs) == 0 {
+			return fmt.Errorf("no private key found in %s", keysDir)
+		}
+		sort.Strings(privateKeyIDs)
+
+		signingKeyID := GetENVWithDefault("JWT_SIGNING_KEY_ID", privateKeyIDs[len(privateKeyIDs)-1])
+		signingKey, exist := ring.keys[signingKeyID]
+		if !exist || signingKey.PrivateKey == nil {
+			return fmt.Errorf("signing key %s not found", signingKeyID)
+		}
+		ring.signingKey = signingKey
+	}
+
+	keyRingMutex.Lock()
+	keyRing = ring
+	keyRingMutex.Unlock()
+
+	return nil
+}
+
+func getJWTKeyRing() (*jwtKeyRing, error) {
+	keyRingMutex.RLock()
+	ring := keyRing
+	keyRingMutex.RUnlock()
+
+	if ring != nil {
+		return ring, nil
+	}
+
+	if err := LoadJWTKeys(); err != nil {
+		return nil, err
+	}
+
+	keyRingMutex.RLock()
+	defer keyRingMutex.RUnlock()
+	return keyRing, nil
+}
+
+// Read pem file into jwtKey, signing method is decided by the key type
+func readJWTKey(file string, kid string) (*jwtKey, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("invalid pem file %s", file)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported pem block %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+	}
+
+	key := &jwtKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = SigningMethodEd25519, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = SigningMethodEd25519, k
+	default:
+		return nil, fmt.Errorf("unsupported key type in %s", file)
+	}
+
+	return key, nil
+}
+
+// SignJWT sign claims using the active signing key, kid header is set so verifier
+// know which key to use
+func SignJWT(claims jwt.Claims) (string, error) {
+	ring, err := getJWTKeyRing()
+	if err != nil {
+		return "", err
+	}
+
+	if ring.signingKey == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ring.hmacSecret)
+	}
+
+	token := jwt.NewWithClaims(ring.signingKey.Method, claims)
+	token.Header["kid"] = ring.signingKey.ID
+
+	return token.SignedString(ring.signingKey.PrivateKey)
+}
+
+// ParseJWT parse and verify token using key referenced by kid header. The algorithm
+// must match the key type to prevent algorithm confusion.
+func ParseJWT(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	ring, err := getJWTKeyRing()
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if ring.signingKey == nil {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method %s", token.Header["alg"])
+			}
+			return ring.hmacSecret, nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, exist := ring.keys[kid]
+		if !exist {
+			return nil, fmt.Errorf("unknown key id %s", kid)
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Header["alg"])
+		}
+
+		return key.PublicKey, nil
+	})
+}
+
+// GetJWKS return every public key inside the key ring, symmetric secret is never published
+func GetJWKS() (JWKS, error) {
+	jwks := JWKS{Keys: []JWK{}}
+
+	ring, err := getJWTKeyRing()
+	if err != nil {
+		return jwks, err
+	}
+
+	for _, key := range ring.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+
+	return jwks, nil
+}
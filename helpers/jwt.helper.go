@@ -1,56 +1,116 @@
 package helpers
 
 import (
+	"errors"
 	"jxb-eprocurement/handlers/dtos"
 	"jxb-eprocurement/models"
-	"os"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 )
 
-func GenerateJWT(user models.USR_User) (string, error) {
-	// Create slice of features
-	features := make([]string, len(user.Role.Features))
+// Purpose of challenge token
+const (
+	ChallengePurposeTwoFactor           = "two_factor"
+	ChallengePurposeTwoFactorEnrollment = "two_factor_enrollment"
+	ChallengePurposePasswordChange      = "password_change"
+)
 
-	for i, feature := range user.Role.Features {
-		features[i] = feature.Name
-	}
+// Lifetime of access token (jwt), configured in minutes using JWT_ACCESS_TIME
+func GetAccessTokenDuration() time.Duration {
+	return time.Duration(GetENVIntWithDefault("JWT_ACCESS_TIME", 15)) * time.Minute
+}
 
-	// Getting jwt related data from env
-	jwtKey := []byte(os.Getenv("JWT_SECRET"))
-	jwtLimitStr := GetENVWithDefault("JWT_TIME", "24")
+// Lifetime of refresh token, configured in hours using JWT_REFRESH_TIME
+func GetRefreshTokenDuration() time.Duration {
+	return time.Duration(GetENVIntWithDefault("JWT_REFRESH_TIME", 168)) * time.Hour
+}
 
-	jwtLimitInt, err := strconv.Atoi(jwtLimitStr)
-	if err != nil {
-		jwtLimitInt = 24
-	}
+func GenerateJWT(user models.USR_User, sessionID uint) (string, *dtos.Claims, error) {
+	return generateAccessToken(user, sessionID, nil, GetAccessTokenDuration())
+}
 
+// GenerateImpersonationJWT create access token of the user on behalf of the actor, the token
+// can't be refreshed so impersonation ends when it expires
+func GenerateImpersonationJWT(user models.USR_User, sessionID uint, actor dtos.ActorClaims, lifetime time.Duration) (string, *dtos.Claims, error) {
+	return generateAccessToken(user, sessionID, &actor, lifetime)
+}
+
+func generateAccessToken(user models.USR_User, sessionID uint, actor *dtos.ActorClaims, lifetime time.Duration) (string, *dtos.Claims, error) {
 	// Set expiration time of jwt token
-	expirationTime := time.Now().Add(time.Duration(jwtLimitInt) * time.Hour)
+	issuedAt := time.Now()
+	expirationTime := issuedAt.Add(lifetime)
 
 	// Create jwt claim to generate jwt token
 	claims := &dtos.Claims{
 		UserID:           user.ID,
 		User:             user.Name,
+		Username:         user.Username,
 		RoleID:           user.Role.ID,
+		RoleIDs:          UserRoleIDs(user),
 		Role:             user.Role.Name,
-		IsAdministrative: user.Role.IsAdministrative,
-		Features:         features,
+		Roles:            UserRoleNames(user),
+		IsAdministrative: IsUserAdministrative(user),
+		Features:         UserFeatureCodes(user),
+		SessionID:        sessionID,
+		Act:              actor,
 		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			Issuer:    GetENVWithDefault("JWT_ISSUER", "jxb-eprocurement"),
 			ExpiresAt: expirationTime.Unix(),
-			IssuedAt:  time.Now().Unix(),
-			NotBefore: time.Now().Unix(),
+			IssuedAt:  issuedAt.Unix(),
+			NotBefore: issuedAt.Unix(),
 		},
 	}
 
-	// Generate jwt token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
+	// Generate jwt token signed by active key
+	tokenString, err := SignJWT(claims)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+
+	return tokenString, claims, nil
+}
+
+// GenerateChallengeToken create short-lived token (JWT_CHALLENGE_TIME in minutes) that prove
+// user already passed a login step, purpose must be checked by the consumer
+func GenerateChallengeToken(userID uint, purpose string) (string, *dtos.ChallengeClaims, error) {
+	issuedAt := time.Now()
+	expirationTime := issuedAt.Add(time.Duration(GetENVIntWithDefault("JWT_CHALLENGE_TIME", 5)) * time.Minute)
+
+	claims := &dtos.ChallengeClaims{
+		UserID:  userID,
+		Purpose: purpose,
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			Issuer:    GetENVWithDefault("JWT_ISSUER", "jxb-eprocurement"),
+			ExpiresAt: expirationTime.Unix(),
+			IssuedAt:  issuedAt.Unix(),
+			NotBefore: issuedAt.Unix(),
+		},
+	}
+
+	tokenString, err := SignJWT(claims)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return tokenString, claims, nil
+}
+
+// ParseChallengeToken verify challenge token and its purpose
+func ParseChallengeToken(tokenString string, purpose string) (*dtos.ChallengeClaims, error) {
+	claims := &dtos.ChallengeClaims{}
+	token, err := ParseJWT(strings.TrimPrefix(tokenString, "Bearer "), claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid || claims.Purpose != purpose || claims.Id == "" {
+		return nil, errors.New("invalid challenge token")
 	}
 
-	return tokenString, nil
+	return claims, nil
 }
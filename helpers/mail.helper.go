@@ -0,0 +1,58 @@
+package helpers
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// MailSender deliver email to user, implementation could be swapped (SMTP, mail api, etc)
+type MailSender interface {
+	Send(to string, subject string, body string) error
+}
+
+// SMTPMailSender send plain text email through SMTP server. Authentication is skipped when
+// username is empty so local SMTP catcher (e.g. MailHog, Mailpit) can be used.
+type SMTPMailSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Create SMTP mail sender configured from SMTP_* and MAIL_FROM env
+func NewSMTPMailSender() *SMTPMailSender {
+	return &SMTPMailSender{
+		Host:     GetENVWithDefault("SMTP_HOST", "localhost"),
+		Port:     GetENVWithDefault("SMTP_PORT", "1025"),
+		Username: GetENVWithDefault("SMTP_USERNAME", ""),
+		Password: GetENVWithDefault("SMTP_PASSWORD", ""),
+		From:     GetENVWithDefault("MAIL_FROM", "no-reply@localhost"),
+	}
+}
+
+func (s *SMTPMailSender) Send(to string, subject string, body string) error {
+	// Header value must not contain line break, otherwise it could inject another header
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return fmt.Errorf("invalid mail header value")
+	}
+
+	message := strings.Join([]string{
+		fmt.Sprintf("From: %s", s.From),
+		fmt.Sprintf("To: %s", to),
+		fmt.Sprintf("Subject: %s", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(s.Host, s.Port), auth, s.From, []string{to}, []byte(message))
+}
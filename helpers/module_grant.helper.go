@@ -0,0 +1,62 @@
+package helpers
+
+import (
+	"jxb-eprocurement/models"
+
+	"gorm.io/gorm"
+)
+
+// moduleTree is every module with its features, used to resolve module grants
+type moduleTree struct {
+	modules  map[uint]*models.USR_Module
+	children map[uint][]uint
+}
+
+// Load every module and its features, features are resolved on every lookup so feature added
+// later to granted module is held by the role right away
+func loadModuleTree(db *gorm.DB) (moduleTree, error) {
+	var modules []models.USR_Module
+	if err := db.Preload("Features").Find(&modules).Error; err != nil {
+		return moduleTree{}, err
+	}
+
+	tree := moduleTree{modules: make(map[uint]*models.USR_Module, len(modules)), children: map[uint][]uint{}}
+	for i := range modules {
+		module := &modules[i]
+		tree.modules[module.ID] = module
+		if module.ParentID != nil {
+			tree.children[*module.ParentID] = append(tree.children[*module.ParentID], module.ID)
+		}
+	}
+	return tree, nil
+}
+
+// Get features of the module and every descendant module
+func (tree moduleTree) features(moduleID uint) []*models.USR_Feature {
+	features := []*models.USR_Feature{}
+	visited := map[uint]struct{}{}
+
+	var walk func(id uint)
+	walk = func(id uint) {
+		module, exist := tree.modules[id]
+		if _, seen := visited[id]; !exist || seen {
+			return
+		}
+		visited[id] = struct{}{}
+
+		// Module of the feature without its features, so the model has no reference cycle
+		info := *module
+		info.Features = nil
+		for i := range module.Features {
+			feature := &module.Features[i]
+			feature.Module = info
+			features = append(features, feature)
+		}
+		for _, child := range tree.children[id] {
+			walk(child)
+		}
+	}
+	walk(moduleID)
+
+	return features
+}
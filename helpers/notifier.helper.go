@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"fmt"
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/models"
+	"net/http"
+	"time"
+)
+
+// Notifier tell user about security event of their account (e.g. login from new device),
+// implementation is chosen by NOTIFIER env: "mail" (default), "log" or "none"
+type Notifier interface {
+	Notify(user models.USR_User, subject string, message string) error
+}
+
+// MailNotifier send the notification to user email
+type MailNotifier struct {
+	Mailer MailSender
+}
+
+func (n *MailNotifier) Notify(user models.USR_User, subject string, message string) error {
+	if user.Email == "" {
+		return nil
+	}
+
+	return n.Mailer.Send(user.Email, subject, fmt.Sprintf("Hello %s,\r\n\r\n%s\r\n", user.Name, message))
+}
+
+// LogNotifier only write the notification into system log, useful for development
+type LogNotifier struct{}
+
+func (n *LogNotifier) Notify(user models.USR_User, subject string, message string) error {
+	handlers.LogSystem(handlers.LogSystemParam{
+		StatusCode: http.StatusOK,
+		Location:   "LogNotifier.Notify",
+		Message:    fmt.Sprintf("Notification | %s | %s", subject, message),
+		StartTime:  time.Now(),
+		EndTime:    time.Now(),
+		UserInfo:   dtos.LogUserInfo{ID: fmt.Sprint(user.ID), Username: user.Username},
+	})
+	return nil
+}
+
+// NoopNotifier discard every notification
+type NoopNotifier struct{}
+
+func (n *NoopNotifier) Notify(user models.USR_User, subject string, message string) error {
+	return nil
+}
+
+// Create notifier configured by NOTIFIER env, mail notifier use the given mailer
+func NewNotifier(mailer MailSender) Notifier {
+	switch GetENVWithDefault("NOTIFIER", "mail") {
+	case "log":
+		return &LogNotifier{}
+	case "none":
+		return &NoopNotifier{}
+	}
+
+	return &MailNotifier{Mailer: mailer}
+}
@@ -0,0 +1,360 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// OIDCConfig is configuration of the external identity provider, configured with OIDC_* env
+type OIDCConfig struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string
+	Scopes        string
+	AutoProvision bool
+	DefaultRoleID uint
+	LinkByEmail   bool // Link identity to existing non administrative user with the same verified email
+}
+
+// OIDCProvider is metadata of identity provider taken from discovery document
+type OIDCProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCAudience accept both single string and array form of "aud" claim
+type OIDCAudience []string
+
+func (a *OIDCAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = OIDCAudience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// OIDCClaims is the claims of id token that used to map identity to user
+type OIDCClaims struct {
+	Issuer            string       `json:"iss"`
+	Subject           string       `json:"sub"`
+	Audience          OIDCAudience `json:"aud"`
+	ExpiresAt         int64        `json:"exp"`
+	IssuedAt          int64        `json:"iat"`
+	Nonce             string       `json:"nonce"`
+	Email             string       `json:"email"`
+	EmailVerified     bool         `json:"email_verified"`
+	Name              string       `json:"name"`
+	PreferredUsername string       `json:"preferred_username"`
+}
+
+// Allowed clock difference between this service and identity provider
+const oidcClockSkew = time.Minute
+
+func (c OIDCClaims) Valid() error {
+	now := time.Now()
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(oidcClockSkew)) {
+		return errors.New("id token has expired")
+	}
+	if c.IssuedAt != 0 && now.Add(oidcClockSkew).Before(time.Unix(c.IssuedAt, 0)) {
+		return errors.New("id token issued in the future")
+	}
+	return nil
+}
+
+// Cache of discovery document and signing keys of identity provider
+var oidcCache = struct {
+	sync.Mutex
+	provider      *OIDCProvider
+	providerUntil time.Time
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}{}
+
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// Get OIDC configuration from env, OIDC login is disabled when OIDC_ISSUER is empty
+func GetOIDCConfig() OIDCConfig {
+	return OIDCConfig{
+		Issuer:        strings.TrimSuffix(GetENVWithDefault("OIDC_ISSUER", ""), "/"),
+		ClientID:      GetENVWithDefault("OIDC_CLIENT_ID", ""),
+		ClientSecret:  GetENVWithDefault("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:   GetENVWithDefault("OIDC_REDIRECT_URL", ""),
+		Scopes:        GetENVWithDefault("OIDC_SCOPES", "openid email profile"),
+		AutoProvision: GetENVBoolWithDefault("OIDC_AUTO_PROVISION", false),
+		DefaultRoleID: uint(GetENVIntWithDefault("OIDC_DEFAULT_ROLE_ID", 0)),
+		LinkByEmail:   GetENVBoolWithDefault("OIDC_LINK_BY_EMAIL", false),
+	}
+}
+
+func (c OIDCConfig) Enabled() bool {
+	return c.Issuer != "" && c.ClientID != "" && c.RedirectURL != ""
+}
+
+// Fetch json document from identity provider
+func oidcGetJSON(endpoint string, target interface{}) error {
+	response, err := oidcHTTPClient.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("identity provider responded %d for %s", response.StatusCode, endpoint)
+	}
+
+	return json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(target)
+}
+
+// Get discovery document of identity provider, cached for an hour
+func GetOIDCProvider(config OIDCConfig) (*OIDCProvider, error) {
+	oidcCache.Lock()
+	defer oidcCache.Unlock()
+
+	if oidcCache.provider != nil && time.Now().Before(oidcCache.providerUntil) {
+		return oidcCache.provider, nil
+	}
+
+	var provider OIDCProvider
+	if err := oidcGetJSON(config.Issuer+"/.well-known/openid-configuration", &provider); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != config.Issuer {
+		return nil, fmt.Errorf("discovery issuer %s doesn't match configured issuer", provider.Issuer)
+	}
+
+	oidcCache.provider = &provider
+	oidcCache.providerUntil = time.Now().Add(time.Hour)
+	oidcCache.keys = nil
+	return &provider, nil
+}
+
+// Convert JWK of identity provider into public key
+func parseOIDCKey(key JWK) (interface{}, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch key.Kty {
+	case "RSA":
+		n, err := decode(key.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(key.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch key.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", key.Crv)
+		}
+		x, err := decode(key.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(key.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		x, err := decode(key.X)
+		if err != nil || key.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("unsupported okp key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %s", key.Kty)
+}
+
+// Get signing key of identity provider by kid, key set is fetched again (at most once a minute)
+// when the kid is unknown so key rotation on provider side is picked up
+func getOIDCKey(provider *OIDCProvider, kid string) (interface{}, error) {
+	oidcCache.Lock()
+	defer oidcCache.Unlock()
+
+	if key, exist := oidcCache.keys[kid]; exist {
+		return key, nil
+	}
+
+	if oidcCache.keys != nil && time.Since(oidcCache.keysFetchedAt) < time.Minute {
+		return nil, fmt.Errorf("unknown signing key %s", kid)
+	}
+
+	var jwks JWKS
+	if err := oidcGetJSON(provider.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := parseOIDCKey(jwk); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	oidcCache.keys = keys
+	oidcCache.keysFetchedAt = time.Now()
+
+	// Provider with single key may omit kid
+	if kid == "" && len(jwks.Keys) == 1 {
+		kid = jwks.Keys[0].Kid
+	}
+
+	key, exist := keys[kid]
+	if !exist {
+		return nil, fmt.Errorf("unknown signing key %s", kid)
+	}
+	return key, nil
+}
+
+// Generate PKCE code verifier and its S256 challenge (RFC 7636)
+func GeneratePKCE() (string, string, error) {
+	verifier, err := GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	hash := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
+
+// Build url of identity provider login page
+func BuildOIDCAuthorizationURL(config OIDCConfig, provider *OIDCProvider, state string, nonce string, codeChallenge string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", config.ClientID)
+	query.Set("redirect_uri", config.RedirectURL)
+	query.Set("scope", config.Scopes)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(provider.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return provider.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// Exchange authorization code with id token on token endpoint
+func ExchangeOIDCCode(config OIDCConfig, provider *OIDCProvider, code string, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", config.RedirectURL)
+	form.Set("client_id", config.ClientID)
+	form.Set("code_verifier", codeVerifier)
+
+	request, err := http.NewRequest(http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if config.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(config.ClientSecret))
+	}
+
+	response, err := oidcHTTPClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	var tokenResponse struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&tokenResponse); err != nil {
+		return "", err
+	}
+
+	if response.StatusCode != http.StatusOK || tokenResponse.IDToken == "" {
+		return "", fmt.Errorf("token exchange failed: %s %s", tokenResponse.Error, tokenResponse.ErrorDescription)
+	}
+
+	return tokenResponse.IDToken, nil
+}
+
+// Verify id token signature, issuer, audience and nonce
+func VerifyOIDCIDToken(config OIDCConfig, provider *OIDCProvider, idToken string, nonce string) (*OIDCClaims, error) {
+	claims := &OIDCClaims{}
+	token, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA, *SigningMethodEdDSA:
+		default:
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		return getOIDCKey(provider, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid id token")
+	}
+
+	if strings.TrimSuffix(claims.Issuer, "/") != config.Issuer {
+		return nil, errors.New("id token issuer mismatch")
+	}
+
+	audienceMatch := false
+	for _, audience := range claims.Audience {
+		if audience == config.ClientID {
+			audienceMatch = true
+		}
+	}
+	if !audienceMatch {
+		return nil, errors.New("id token audience mismatch")
+	}
+
+	if claims.Nonce != nonce {
+		return nil, errors.New("id token nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+
+	return claims, nil
+}
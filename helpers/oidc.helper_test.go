@@ -0,0 +1,249 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Identity provider stub serving discovery document and the signing keys, keys can be rotated
+type oidcProviderStub struct {
+	sync.Mutex
+	server    *httptest.Server
+	issuer    string
+	keys      map[string]*rsa.PrivateKey
+	jwksFetch int
+}
+
+func newOIDCProviderStub(t *testing.T) *oidcProviderStub {
+	stub := &oidcProviderStub{keys: map[string]*rsa.PrivateKey{}}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.Lock()
+		defer stub.Unlock()
+
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(OIDCProvider{
+				Issuer:                stub.issuer,
+				AuthorizationEndpoint: stub.server.URL + "/authorize",
+				TokenEndpoint:         stub.server.URL + "/token",
+				JWKSURI:               stub.server.URL + "/jwks",
+			})
+		case "/jwks":
+			stub.jwksFetch++
+			jwks := JWKS{Keys: []JWK{}}
+			for kid, key := range stub.keys {
+				jwks.Keys = append(jwks.Keys, JWK{
+					Kty: "RSA",
+					Kid: kid,
+					Use: "sig",
+					Alg: "RS256",
+					N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				})
+			}
+			json.NewEncoder(w).Encode(jwks)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	stub.issuer = stub.server.URL
+	t.Cleanup(stub.server.Close)
+
+	// Cache is global, every test start with empty one
+	resetOIDCCache()
+	t.Cleanup(resetOIDCCache)
+
+	return stub
+}
+
+func resetOIDCCache() {
+	oidcCache.Lock()
+	defer oidcCache.Unlock()
+	oidcCache.provider = nil
+	oidcCache.keys = nil
+}
+
+// Replace served signing keys with new key of each kid
+func (s *oidcProviderStub) rotate(t *testing.T, kids ...string) map[string]*rsa.PrivateKey {
+	keys := map[string]*rsa.PrivateKey{}
+	for _, kid := range kids {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("generate key: %v", err)
+		}
+		keys[kid] = key
+	}
+
+	s.Lock()
+	s.keys = keys
+	s.Unlock()
+	return keys
+}
+
+func (s *oidcProviderStub) config() OIDCConfig {
+	return OIDCConfig{Issuer: s.issuer, ClientID: "eprocurement", RedirectURL: "http://localhost/callback"}
+}
+
+func signIDToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	return signed
+}
+
+func idTokenClaims(issuer string, override jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss":   issuer,
+		"sub":   "subject-1",
+		"aud":   "eprocurement",
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": "nonce-1",
+		"email": "vendor@example.com",
+	}
+	for key, value := range override {
+		if value == nil {
+			delete(claims, key)
+			continue
+		}
+		claims[key] = value
+	}
+	return claims
+}
+
+func TestVerifyOIDCIDToken(t *testing.T) {
+	stub := newOIDCProviderStub(t)
+	keys := stub.rotate(t, "key-1")
+	config := stub.config()
+
+	provider, err := GetOIDCProvider(config)
+	if err != nil {
+		t.Fatalf("get provider: %v", err)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	tests := map[string]struct {
+		token string
+		err   string
+	}{
+		"valid token": {
+			token: signIDToken(t, keys["key-1"], "key-1", idTokenClaims(config.Issuer, nil)),
+		},
+		"audience in array": {
+			token: signIDToken(t, keys["key-1"], "key-1", idTokenClaims(config.Issuer, jwt.MapClaims{"aud": []string{"other", "eprocurement"}})),
+		},
+		"other issuer": {
+			token: signIDToken(t, keys["key-1"], "key-1", idTokenClaims("https://attacker.example.com", nil)),
+			err:   "issuer mismatch",
+		},
+		"other audience": {
+			token: signIDToken(t, keys["key-1"], "key-1", idTokenClaims(config.Issuer, jwt.MapClaims{"aud": []string{"other"}})),
+			err:   "audience mismatch",
+		},
+		"other nonce": {
+			token: signIDToken(t, keys["key-1"], "key-1", idTokenClaims(config.Issuer, jwt.MapClaims{"nonce": "replayed"})),
+			err:   "nonce mismatch",
+		},
+		"expired token": {
+			token: signIDToken(t, keys["key-1"], "key-1", idTokenClaims(config.Issuer, jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})),
+			err:   "expired",
+		},
+		"without subject": {
+			token: signIDToken(t, keys["key-1"], "key-1", idTokenClaims(config.Issuer, jwt.MapClaims{"sub": nil})),
+			err:   "no subject",
+		},
+		"signed by other key": {
+			token: signIDToken(t, otherKey, "key-1", idTokenClaims(config.Issuer, nil)),
+			err:   "verification error",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims, err := VerifyOIDCIDToken(config, provider, test.token, "nonce-1")
+			if test.err == "" {
+				if err != nil {
+					t.Fatalf("verify id token: %v", err)
+				}
+				if claims.Subject != "subject-1" || claims.Email != "vendor@example.com" {
+					t.Fatalf("unexpected claims %+v", claims)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestGetOIDCProviderRejectsOtherIssuer(t *testing.T) {
+	stub := newOIDCProviderStub(t)
+	stub.issuer = "https://attacker.example.com"
+
+	if _, err := GetOIDCProvider(stub.config()); err == nil {
+		t.Fatal("discovery document of other issuer is accepted")
+	}
+}
+
+func TestGetOIDCKeyPicksUpRotation(t *testing.T) {
+	stub := newOIDCProviderStub(t)
+	oldKeys := stub.rotate(t, "key-1")
+	config := stub.config()
+
+	provider, err := GetOIDCProvider(config)
+	if err != nil {
+		t.Fatalf("get provider: %v", err)
+	}
+	if _, err := VerifyOIDCIDToken(config, provider, signIDToken(t, oldKeys["key-1"], "key-1", idTokenClaims(config.Issuer, nil)), "nonce-1"); err != nil {
+		t.Fatalf("verify token of current key: %v", err)
+	}
+
+	// Known key is served from cache
+	if _, err := getOIDCKey(provider, "key-1"); err != nil {
+		t.Fatalf("get cached key: %v", err)
+	}
+	if stub.jwksFetch != 1 {
+		t.Fatalf("jwks fetched %d times, want 1", stub.jwksFetch)
+	}
+
+	newKeys := stub.rotate(t, "key-2")
+	newToken := signIDToken(t, newKeys["key-2"], "key-2", idTokenClaims(config.Issuer, nil))
+
+	// Unknown kid doesn't fetch the key set again within a minute
+	if _, err := VerifyOIDCIDToken(config, provider, newToken, "nonce-1"); err == nil || !strings.Contains(err.Error(), "unknown signing key") {
+		t.Fatalf("got error %v, want unknown signing key", err)
+	}
+	if stub.jwksFetch != 1 {
+		t.Fatalf("jwks fetched %d times, want 1", stub.jwksFetch)
+	}
+
+	// Once a minute passed the rotated key is fetched
+	oidcCache.Lock()
+	oidcCache.keysFetchedAt = time.Now().Add(-2 * time.Minute)
+	oidcCache.Unlock()
+
+	if _, err := VerifyOIDCIDToken(config, provider, newToken, "nonce-1"); err != nil {
+		t.Fatalf("verify token of rotated key: %v", err)
+	}
+	if stub.jwksFetch != 2 {
+		t.Fatalf("jwks fetched %d times, want 2", stub.jwksFetch)
+	}
+}
@@ -0,0 +1,181 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// PasswordHasher hash and verify password. Hash carry its algorithm and parameter so hash made
+// with older algorithm or parameter can still be verified and detected for rehash.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash string, password string) (bool, error)
+	NeedsRehash(hash string) bool
+}
+
+// Argon2idHasher produce argon2id hash in PHC string format:
+// $argon2id$v=19$m=<memory KiB>,t=<iterations>,p=<parallelism>$<salt>$<key>
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	encode := base64.RawStdEncoding.EncodeToString
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Iterations, h.Parallelism, encode(salt), encode(key)), nil
+}
+
+// Parse PHC string of argon2id hash, parameter is bounded so malformed hash can't exhaust the server
+func parseArgon2idHash(hash string) (argon2idParams, error) {
+	var params argon2idParams
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, fmt.Errorf("unsupported argon2 version %s", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return params, err
+	}
+	if params.memory == 0 || params.memory > 4*1024*1024 || params.iterations == 0 || params.iterations > 100 || params.parallelism == 0 {
+		return params, errors.New("invalid argon2 parameter")
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, err
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, err
+	}
+	if len(params.key) == 0 || len(params.key) > 1024 {
+		return params, errors.New("invalid argon2 key length")
+	}
+
+	return params, nil
+}
+
+func (h *Argon2idHasher) Verify(hash string, password string) (bool, error) {
+	params, err := parseArgon2idHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, err := parseArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.memory != h.Memory || params.iterations != h.Iterations || params.parallelism != h.Parallelism ||
+		uint32(len(params.salt)) != h.SaltLength || uint32(len(params.key)) != h.KeyLength
+}
+
+// BcryptHasher is the previous password hashing, kept so existing hash can be verified
+type BcryptHasher struct {
+	Cost int
+}
+
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+func (h *BcryptHasher) Verify(hash string, password string) (bool, error) {
+	if !isBcryptHash(hash) {
+		return false, ErrUnknownPasswordHash
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+// Get password hasher configured by PASSWORD_HASH_ALGORITHM env ("argon2id" or "bcrypt"),
+// argon2id parameter is configured by ARGON2_* env and bcrypt cost by BCRYPT_COST env
+func GetPasswordHasher() PasswordHasher {
+	if GetENVWithDefault("PASSWORD_HASH_ALGORITHM", "argon2id") == "bcrypt" {
+		return &BcryptHasher{Cost: GetENVIntWithDefault("BCRYPT_COST", bcrypt.DefaultCost)}
+	}
+
+	return &Argon2idHasher{
+		Memory:      uint32(GetENVIntWithDefault("ARGON2_MEMORY", 64*1024)),
+		Iterations:  uint32(GetENVIntWithDefault("ARGON2_ITERATIONS", 3)),
+		Parallelism: uint8(GetENVIntWithDefault("ARGON2_PARALLELISM", 2)),
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Hash password using configured hasher
+func HashPassword(password string) (string, error) {
+	return GetPasswordHasher().Hash(password)
+}
+
+// Verify password against hash of any supported algorithm
+func VerifyPassword(hash string, password string) bool {
+	var hasher PasswordHasher = &Argon2idHasher{}
+	if isBcryptHash(hash) {
+		hasher = &BcryptHasher{}
+	}
+
+	valid, err := hasher.Verify(hash, password)
+	return err == nil && valid
+}
+
+// Check whether hash was made by other algorithm or parameter than the configured hasher
+func PasswordNeedsRehash(hash string) bool {
+	hasher := GetPasswordHasher()
+	if _, isBcrypt := hasher.(*BcryptHasher); isBcrypt != isBcryptHash(hash) {
+		return true
+	}
+
+	return hasher.NeedsRehash(hash)
+}
@@ -0,0 +1,162 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"jxb-eprocurement/models"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// PasswordPolicy is rule that every new password must follow, configured with PASSWORD_* env
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	HistoryCount  int
+	MaxAge        time.Duration
+}
+
+// Common password that is rejected regardless of the other rules (compared case insensitive)
+var commonPasswords = map[string]struct{}{
+	"123456": {}, "12345678": {}, "123456789": {}, "1234567890": {}, "12345": {}, "1234567": {},
+	"111111": {}, "000000": {}, "123123": {}, "654321": {}, "666666": {}, "121212": {},
+	"password": {}, "password1": {}, "password123": {}, "passw0rd": {}, "p@ssw0rd": {}, "p@ssword1": {},
+	"qwerty": {}, "qwerty123": {}, "qwertyuiop": {}, "asdfgh": {}, "asdfghjkl": {}, "zxcvbnm": {},
+	"1q2w3e4r": {}, "1qaz2wsx": {}, "abc123": {}, "abcd1234": {}, "aa123456": {}, "a1b2c3d4": {},
+	"iloveyou": {}, "admin": {}, "admin123": {}, "administrator": {}, "root": {}, "toor": {},
+	"welcome": {}, "welcome1": {}, "welcome123": {}, "letmein": {}, "login": {}, "master": {},
+	"monkey": {}, "dragon": {}, "football": {}, "baseball": {}, "sunshine": {}, "princess": {},
+	"superman": {}, "trustno1": {}, "changeme": {}, "secret": {}, "default": {}, "guest": {},
+	"starwars": {}, "whatever": {}, "shadow": {}, "michael": {}, "jakarta": {}, "indonesia": {},
+	"bismillah": {}, "rahasia": {}, "sayang": {}, "katasandi": {},
+}
+
+// Build password policy from env, PASSWORD_MAX_AGE_DAYS 0 means password never expired
+func GetPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:     GetENVIntWithDefault("PASSWORD_MIN_LENGTH", 8),
+		RequireUpper:  GetENVBoolWithDefault("PASSWORD_REQUIRE_UPPERCASE", true),
+		RequireLower:  GetENVBoolWithDefault("PASSWORD_REQUIRE_LOWERCASE", true),
+		RequireDigit:  GetENVBoolWithDefault("PASSWORD_REQUIRE_DIGIT", true),
+		RequireSymbol: GetENVBoolWithDefault("PASSWORD_REQUIRE_SYMBOL", false),
+		HistoryCount:  GetENVIntWithDefault("PASSWORD_HISTORY_COUNT", 5),
+		MaxAge:        time.Duration(GetENVIntWithDefault("PASSWORD_MAX_AGE_DAYS", 0)) * 24 * time.Hour,
+	}
+}
+
+// Validate password against length, character class and denylist rule. Identities (username,
+// email, etc) can't be used as password either.
+func (p PasswordPolicy) Validate(password string, identities ...string) error {
+	var violations []string
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char) || unicode.IsSpace(char):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		violations = append(violations, "an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		violations = append(violations, "a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, "a symbol")
+	}
+
+	if len(violations) != 0 {
+		return fmt.Errorf("Password must contain %s", strings.Join(violations, ", "))
+	}
+
+	lowered := strings.ToLower(password)
+	if _, exist := commonPasswords[lowered]; exist {
+		return errors.New("Password is too common")
+	}
+
+	for _, identity := range identities {
+		if identity != "" && strings.EqualFold(strings.TrimSpace(identity), password) {
+			return errors.New("Password must not be the same as username or email")
+		}
+	}
+
+	return nil
+}
+
+// Check whether password of the user already reach maximum age, user that never changed
+// password is counted from the time the account created
+func (p PasswordPolicy) IsExpired(user models.USR_User) bool {
+	if p.MaxAge <= 0 {
+		return false
+	}
+
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+
+	return time.Since(changedAt) > p.MaxAge
+}
+
+// Check whether password is the current password or one of the last HistoryCount passwords
+func (p PasswordPolicy) IsReused(db *gorm.DB, user models.USR_User, password string) (bool, error) {
+	if user.Password != "" && VerifyPassword(user.Password, password) {
+		return true, nil
+	}
+
+	if p.HistoryCount <= 0 {
+		return false, nil
+	}
+
+	var histories []models.USR_PasswordHistory
+	if err := db.Where("user_id = ?", user.ID).Order("id desc").Limit(p.HistoryCount).Find(&histories).Error; err != nil {
+		return false, err
+	}
+
+	for _, history := range histories {
+		if VerifyPassword(history.PasswordHash, password) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Keep hash of the new password in history and drop entries older than HistoryCount
+func (p PasswordPolicy) RecordHistory(tx *gorm.DB, userID uint, passwordHash string) error {
+	if p.HistoryCount <= 0 {
+		return nil
+	}
+
+	if err := tx.Create(&models.USR_PasswordHistory{UserID: userID, PasswordHash: passwordHash}).Error; err != nil {
+		return err
+	}
+
+	var keptIDs []uint
+	if err := tx.Model(&models.USR_PasswordHistory{}).Where("user_id = ?", userID).Order("id desc").Limit(p.HistoryCount).Pluck("id", &keptIDs).Error; err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Where("user_id = ? AND id NOT IN ?", userID, keptIDs).Delete(&models.USR_PasswordHistory{}).Error
+}
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"jxb-eprocurement/handlers/dtos"
 	"jxb-eprocurement/models"
 
 	"github.com/gin-gonic/gin"
@@ -33,3 +34,17 @@ func GetRolePayload(c *gin.Context, role *models.USR_Role) {
 
 	*role = *roleData
 }
+
+func GetClaimsPayload(c *gin.Context, claims *dtos.Claims) {
+	claimsPayload, exist := c.Get("claims")
+	if !exist {
+		return
+	}
+
+	claimsData, ok := claimsPayload.(*dtos.Claims)
+	if !ok {
+		return
+	}
+
+	*claims = *claimsData
+}
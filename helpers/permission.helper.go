@@ -0,0 +1,104 @@
+package helpers
+
+import (
+	"jxb-eprocurement/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserPermission is the current access of a user resolved from the roles in database. RoleID and
+// Role is the primary role, features and administrative flag are effective value of every role.
+type UserPermission struct {
+	UserID           uint
+	RoleID           uint
+	RoleIDs          []uint
+	Status           string
+	Role             string
+	Roles            []string
+	IsAdministrative bool
+	Features         []string
+	FeatureScopes    map[string]string // Broadest data scope of each feature
+	OrganizationUnit string
+	expiresAt        time.Time
+}
+
+// Cache of resolved permission per user. Entries are invalidated by the services that change
+// role, feature or user role, ttl (PERMISSION_CACHE_TTL in seconds) only act as safety net
+// when the application run in more than one instance.
+var permissionCache = struct {
+	sync.RWMutex
+	entries map[uint]UserPermission
+}{entries: map[uint]UserPermission{}}
+
+// ResolveUserPermission return the current roles and features of a user, cached after first lookup
+func ResolveUserPermission(db *gorm.DB, userID uint) (UserPermission, error) {
+	permissionCache.RLock()
+	permission, exist := permissionCache.entries[userID]
+	permissionCache.RUnlock()
+
+	if exist && permission.expiresAt.After(time.Now()) {
+		return permission, nil
+	}
+
+	var user models.USR_User
+	result := PreloadUserRoles(db).Limit(1).Where("id = ?", userID).Find(&user)
+	if result.Error != nil {
+		return UserPermission{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return UserPermission{}, gorm.ErrRecordNotFound
+	}
+	if err := InheritUserRoleFeatures(db, &user); err != nil {
+		return UserPermission{}, err
+	}
+
+	permission = UserPermission{
+		UserID:           user.ID,
+		RoleID:           user.Role.ID,
+		RoleIDs:          UserRoleIDs(user),
+		Status:           user.Status,
+		Role:             user.Role.Name,
+		Roles:            UserRoleNames(user),
+		IsAdministrative: IsUserAdministrative(user),
+		Features:         UserFeatureCodes(user),
+		FeatureScopes:    userFeatureScopes(user),
+		OrganizationUnit: user.OrganizationUnit,
+		expiresAt:        time.Now().Add(time.Duration(GetENVIntWithDefault("PERMISSION_CACHE_TTL", 300)) * time.Second),
+	}
+
+	permissionCache.Lock()
+	permissionCache.entries[userID] = permission
+	permissionCache.Unlock()
+
+	return permission, nil
+}
+
+// Drop cached permission of a user, used when the user role changed or user deleted
+func InvalidateUserPermission(userID uint) {
+	permissionCache.Lock()
+	delete(permissionCache.entries, userID)
+	permissionCache.Unlock()
+}
+
+// Drop cached permission of every user that hold the role, used when role features changed
+func InvalidateRolePermission(roleID uint) {
+	permissionCache.Lock()
+	for userID, permission := range permissionCache.entries {
+		for _, id := range permission.RoleIDs {
+			if id == roleID {
+				delete(permissionCache.entries, userID)
+				break
+			}
+		}
+	}
+	permissionCache.Unlock()
+}
+
+// Drop every cached permission, used when feature changed
+func InvalidatePermissions() {
+	permissionCache.Lock()
+	permissionCache.entries = map[uint]UserPermission{}
+	permissionCache.Unlock()
+}
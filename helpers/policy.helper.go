@@ -0,0 +1,304 @@
+package helpers
+
+import (
+	"fmt"
+	"jxb-eprocurement/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PolicyResourceLoader load attributes of resource by id of the route, keyed by feature code
+// prefix (module part of the code)
+type PolicyResourceLoader func(db *gorm.DB, id string) (map[string]interface{}, error)
+
+var policyResourceLoaders = struct {
+	sync.RWMutex
+	loaders map[string]PolicyResourceLoader
+}{loaders: map[string]PolicyResourceLoader{"user": loadUserPolicyResource}}
+
+// RegisterPolicyResource set loader of resource attributes for features of a module, e.g.
+// "procurement" loader is used for "procurement.update"
+func RegisterPolicyResource(prefix string, loader PolicyResourceLoader) {
+	policyResourceLoaders.Lock()
+	policyResourceLoaders.loaders[prefix] = loader
+	policyResourceLoaders.Unlock()
+}
+
+func loadUserPolicyResource(db *gorm.DB, id string) (map[string]interface{}, error) {
+	var user models.USR_User
+	result := db.Preload("Role").Preload("Roles").Limit(1).Where("id = ?", id).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+
+	return map[string]interface{}{
+		"id":                 user.ID,
+		"username":           user.Username,
+		"status":             user.Status,
+		"organization_unit":  user.OrganizationUnit,
+		"is_service_account": user.IsServiceAccount,
+		"is_administrative":  IsUserAdministrative(user),
+		"role_ids":           UserRoleIDs(user),
+		"roles":              UserRoleNames(user),
+	}, nil
+}
+
+// PolicyResult is outcome of a single policy, used to explain the decision
+type PolicyResult struct {
+	PolicyID   uint   `json:"policy_id"`
+	Name       string `json:"name"`
+	Feature    string `json:"feature_code"`
+	Effect     string `json:"effect"`
+	Expression string `json:"expression"`
+	Matched    bool   `json:"matched"`
+	Denied     bool   `json:"denied"`
+	Error      string `json:"error,omitempty"`
+}
+
+// PolicyDecision is result of evaluating every applicable policy, Features are the granted
+// features whose policies passed
+type PolicyDecision struct {
+	Allowed  bool           `json:"allowed"`
+	Reason   string         `json:"reason"`
+	Features []string       `json:"features"`
+	Policies []PolicyResult `json:"policies"`
+}
+
+// Cache of active policies, invalidated by policy service. Ttl (PERMISSION_CACHE_TTL) only act
+// as safety net when the application run in more than one instance.
+var policyCache = struct {
+	sync.RWMutex
+	policies  []models.USR_Policy
+	expiresAt time.Time
+}{}
+
+// GetActivePolicies return active policies attached to any of the features
+func GetActivePolicies(db *gorm.DB, features []string) ([]models.USR_Policy, error) {
+	policyCache.RLock()
+	policies, expiresAt := policyCache.policies, policyCache.expiresAt
+	policyCache.RUnlock()
+
+	if policies == nil || expiresAt.Before(time.Now()) {
+		policies = []models.USR_Policy{}
+		if err := db.Where("is_active = ?", true).Order("id").Find(&policies).Error; err != nil {
+			return nil, err
+		}
+
+		policyCache.Lock()
+		policyCache.policies = policies
+		policyCache.expiresAt = time.Now().Add(time.Duration(GetENVIntWithDefault("PERMISSION_CACHE_TTL", 300)) * time.Second)
+		policyCache.Unlock()
+	}
+
+	featureMap := make(map[string]struct{}, len(features))
+	for _, feature := range features {
+		featureMap[feature] = struct{}{}
+	}
+
+	applicable := []models.USR_Policy{}
+	for _, policy := range policies {
+		if _, exist := featureMap[policy.FeatureCode]; exist {
+			applicable = append(applicable, policy)
+		}
+	}
+	return applicable, nil
+}
+
+// Drop cached policies, used when policy changed
+func InvalidatePolicies() {
+	policyCache.Lock()
+	policyCache.policies = nil
+	policyCache.Unlock()
+}
+
+// PolicySubject build subject attributes from resolved permission of the user
+func PolicySubject(permission UserPermission) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                permission.UserID,
+		"status":            permission.Status,
+		"role_ids":          permission.RoleIDs,
+		"roles":             permission.Roles,
+		"features":          permission.Features,
+		"is_administrative": permission.IsAdministrative,
+		"organization_unit": permission.OrganizationUnit,
+	}
+}
+
+// PolicyEnvironment build environment attributes of the request
+func PolicyEnvironment(c *gin.Context, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"time":    now.Format(time.RFC3339),
+		"date":    now.Format("2006-01-02"),
+		"clock":   now.Format("15:04"),
+		"hour":    now.Hour(),
+		"weekday": strings.ToLower(now.Weekday().String()),
+		"ip":      c.ClientIP(),
+		"method":  c.Request.Method,
+		"path":    c.FullPath(),
+	}
+}
+
+// LoadPolicyResource load attributes of the resource identified by id using loader of the
+// features module, resource without loader only has the id
+func LoadPolicyResource(db *gorm.DB, features []string, id string) (map[string]interface{}, error) {
+	resource := map[string]interface{}{}
+	if id == "" {
+		return resource, nil
+	}
+	if number, err := strconv.ParseUint(id, 10, 64); err == nil {
+		resource["id"] = number
+	} else {
+		resource["id"] = id
+	}
+
+	policyResourceLoaders.RLock()
+	defer policyResourceLoaders.RUnlock()
+
+	for _, feature := range features {
+		loader, exist := policyResourceLoaders.loaders[strings.SplitN(feature, ".", 2)[0]]
+		if !exist {
+			continue
+		}
+
+		attributes, err := loader(db, id)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range attributes {
+			resource[key] = value
+		}
+		break
+	}
+	return resource, nil
+}
+
+// EvaluatePolicies decide the request from the policies, every policy must pass. Policy with
+// invalid expression or evaluation error deny the request.
+func EvaluatePolicies(policies []models.USR_Policy, attributes map[string]interface{}) PolicyDecision {
+	decision := PolicyDecision{Allowed: true, Reason: "No policy denied the request", Policies: []PolicyResult{}}
+	if len(policies) == 0 {
+		decision.Reason = "No policy applies"
+	}
+
+	for _, policy := range policies {
+		result := PolicyResult{
+			PolicyID:   policy.ID,
+			Name:       policy.Name,
+			Feature:    policy.FeatureCode,
+			Effect:     policy.Effect,
+			Expression: policy.Expression,
+		}
+
+		expression, err := ParsePolicyExpression(policy.Expression)
+		if err == nil {
+			result.Matched, err = expression.Evaluate(attributes)
+		}
+
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+			result.Denied = true
+		case policy.Effect == models.PolicyEffectDeny:
+			result.Denied = result.Matched
+		default:
+			result.Denied = !result.Matched
+		}
+
+		if result.Denied && decision.Allowed {
+			decision.Allowed = false
+			switch {
+			case result.Error != "":
+				decision.Reason = fmt.Sprintf("Policy %s failed to evaluate: %s", policy.Name, result.Error)
+			case policy.Effect == models.PolicyEffectDeny:
+				decision.Reason = fmt.Sprintf("Denied by policy %s", policy.Name)
+			default:
+				decision.Reason = fmt.Sprintf("Condition of policy %s is not met", policy.Name)
+			}
+		}
+		decision.Policies = append(decision.Policies, result)
+	}
+
+	return decision
+}
+
+// EvaluateFeaturePolicies decide the request from the policies of each granted feature. Like
+// allowed features of a route, the features are alternatives: request is allowed when every
+// policy of at least one feature pass. Attributes are built per feature since the resource is
+// loaded by the feature module.
+func EvaluateFeaturePolicies(policies []models.USR_Policy, features []string, attributes func(feature string) (map[string]interface{}, error)) (PolicyDecision, error) {
+	featurePolicies := map[string][]models.USR_Policy{}
+	for _, policy := range policies {
+		featurePolicies[policy.FeatureCode] = append(featurePolicies[policy.FeatureCode], policy)
+	}
+
+	decision := PolicyDecision{Features: []string{}, Policies: []PolicyResult{}}
+	deniedReason := ""
+	for _, feature := range features {
+		if len(featurePolicies[feature]) == 0 {
+			decision.Features = append(decision.Features, feature)
+			continue
+		}
+
+		featureAttributes, err := attributes(feature)
+		if err != nil {
+			return PolicyDecision{}, err
+		}
+		featureDecision := EvaluatePolicies(featurePolicies[feature], featureAttributes)
+		decision.Policies = append(decision.Policies, featureDecision.Policies...)
+		if featureDecision.Allowed {
+			decision.Features = append(decision.Features, feature)
+		} else if deniedReason == "" {
+			deniedReason = featureDecision.Reason
+		}
+	}
+
+	decision.Allowed = len(decision.Features) != 0
+	switch {
+	case len(decision.Policies) == 0:
+		decision.Reason = "No policy applies"
+	case !decision.Allowed:
+		decision.Reason = deniedReason
+	case len(featurePolicies[decision.Features[0]]) == 0:
+		decision.Reason = fmt.Sprintf("No policy applies to feature %s", decision.Features[0])
+	default:
+		decision.Reason = fmt.Sprintf("Policies of feature %s passed", decision.Features[0])
+	}
+	return decision, nil
+}
+
+// AuthorizePolicies evaluate policies of the granted features on the accessed route
+func AuthorizePolicies(db *gorm.DB, c *gin.Context, permission UserPermission, features []string, request AccessRequest) (PolicyDecision, error) {
+	policies, err := GetActivePolicies(db, features)
+	if err != nil || len(policies) == 0 {
+		return PolicyDecision{Allowed: len(features) != 0, Reason: "No policy applies", Features: features, Policies: []PolicyResult{}}, err
+	}
+
+	subject := PolicySubject(permission)
+	_, subject["impersonated"] = c.Get("impersonator_id")
+	_, subject["api_key"] = c.Get("api_key_features")
+
+	environment := PolicyEnvironment(c, time.Now())
+	if request.Method != "" {
+		environment["method"] = request.Method
+	}
+	if request.Path != "" {
+		environment["path"] = request.Path
+	}
+
+	return EvaluateFeaturePolicies(policies, features, func(feature string) (map[string]interface{}, error) {
+		resource, err := LoadPolicyResource(db, []string{feature}, request.ResourceID)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"subject":  subject,
+			"resource": resource,
+			"env":      environment,
+		}, nil
+	})
+}
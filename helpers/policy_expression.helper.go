@@ -0,0 +1,473 @@
+package helpers
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PolicyExpression is parsed policy rule, e.g.
+//
+//	resource.status == "draft" and subject.organization_unit == resource.organization_unit
+//	subject.id in resource.committee_ids or "Admin" in subject.roles
+//
+// Supported: and / or / not (&& || !), == != < <= > >=, in, parentheses, list [a, b],
+// string, number, true, false, null and attribute path (subject.*, resource.*, env.*).
+type PolicyExpression struct {
+	root policyNode
+}
+
+type policyNode interface {
+	eval(attributes map[string]interface{}) (interface{}, error)
+}
+
+type policyLiteral struct{ value interface{} }
+
+type policyPath struct{ path []string }
+
+type policyList struct{ items []policyNode }
+
+type policyUnary struct {
+	operator string
+	operand  policyNode
+}
+
+type policyBinary struct {
+	operator string
+	left     policyNode
+	right    policyNode
+}
+
+type policyToken struct {
+	kind  string // "ident", "string", "number", "op", "eof"
+	value string
+}
+
+// Split expression into tokens
+func tokenizePolicy(source string) ([]policyToken, error) {
+	tokens := []policyToken{}
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			var builder strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				builder.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			tokens = append(tokens, policyToken{kind: "string", value: builder.String()})
+			i = j + 1
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]) && expectOperand(tokens)):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, policyToken{kind: "number", value: string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, policyToken{kind: "ident", value: string(runes[i:j])})
+			i = j
+		default:
+			two := ""
+			if i+1 < len(runes) {
+				two = string(runes[i : i+2])
+			}
+			switch two {
+			case "==", "!=", "<=", ">=", "&&", "||":
+				tokens = append(tokens, policyToken{kind: "op", value: two})
+				i += 2
+				continue
+			}
+			if strings.ContainsRune("<>!()[],", r) {
+				tokens = append(tokens, policyToken{kind: "op", value: string(r)})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("unexpected character %q at %d", r, i)
+		}
+	}
+
+	return append(tokens, policyToken{kind: "eof"}), nil
+}
+
+// Minus is part of number only where operand is expected (expression has no subtraction)
+func expectOperand(tokens []policyToken) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == "op" && last.value != ")" && last.value != "]"
+}
+
+type policyParser struct {
+	tokens []policyToken
+	pos    int
+}
+
+func (p *policyParser) peek() policyToken {
+	return p.tokens[p.pos]
+}
+
+func (p *policyParser) next() policyToken {
+	token := p.tokens[p.pos]
+	if token.kind != "eof" {
+		p.pos++
+	}
+	return token
+}
+
+// Check next token is the operator or keyword, consumed when it is
+func (p *policyParser) accept(values ...string) (string, bool) {
+	token := p.peek()
+	if token.kind != "op" && token.kind != "ident" {
+		return "", false
+	}
+	for _, value := range values {
+		if token.value == value {
+			p.next()
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (p *policyParser) parseOr() (policyNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("or", "||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = policyBinary{operator: "or", left: left, right: right}
+	}
+}
+
+func (p *policyParser) parseAnd() (policyNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("and", "&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = policyBinary{operator: "and", left: left, right: right}
+	}
+}
+
+func (p *policyParser) parseNot() (policyNode, error) {
+	if _, ok := p.accept("not", "!"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return policyUnary{operator: "not", operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *policyParser) parseComparison() (policyNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	// "not in" is the only two word operator
+	if p.peek().value == "not" && p.tokens[p.pos+1].value == "in" {
+		p.next()
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return policyUnary{operator: "not", operand: policyBinary{operator: "in", left: left, right: right}}, nil
+	}
+
+	if operator, ok := p.accept("==", "!=", "<", "<=", ">", ">=", "in"); ok {
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return policyBinary{operator: operator, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *policyParser) parseOperand() (policyNode, error) {
+	token := p.next()
+	switch token.kind {
+	case "string":
+		return policyLiteral{value: token.value}, nil
+	case "number":
+		number, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", token.value)
+		}
+		return policyLiteral{value: number}, nil
+	case "ident":
+		switch token.value {
+		case "true":
+			return policyLiteral{value: true}, nil
+		case "false":
+			return policyLiteral{value: false}, nil
+		case "null":
+			return policyLiteral{value: nil}, nil
+		case "and", "or", "not", "in":
+			return nil, fmt.Errorf("unexpected %s", token.value)
+		}
+		path := strings.Split(token.value, ".")
+		switch path[0] {
+		case "subject", "resource", "env":
+		default:
+			return nil, fmt.Errorf("unknown attribute %s, attribute must start with subject, resource or env", token.value)
+		}
+		return policyPath{path: path}, nil
+	case "op":
+		switch token.value {
+		case "(":
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, fmt.Errorf("missing )")
+			}
+			return node, nil
+		case "[":
+			list := policyList{}
+			if _, ok := p.accept("]"); ok {
+				return list, nil
+			}
+			for {
+				item, err := p.parseOperand()
+				if err != nil {
+					return nil, err
+				}
+				list.items = append(list.items, item)
+				if _, ok := p.accept("]"); ok {
+					return list, nil
+				}
+				if _, ok := p.accept(","); !ok {
+					return nil, fmt.Errorf("missing , or ] in list")
+				}
+			}
+		}
+	case "eof":
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %s", token.value)
+}
+
+// ParsePolicyExpression parse policy rule, used to validate rule before it is saved
+func ParsePolicyExpression(source string) (PolicyExpression, error) {
+	tokens, err := tokenizePolicy(source)
+	if err != nil {
+		return PolicyExpression{}, err
+	}
+
+	parser := &policyParser{tokens: tokens}
+	root, err := parser.parseOr()
+	if err != nil {
+		return PolicyExpression{}, err
+	}
+	if token := parser.peek(); token.kind != "eof" {
+		return PolicyExpression{}, fmt.Errorf("unexpected %s", token.value)
+	}
+
+	return PolicyExpression{root: root}, nil
+}
+
+// Evaluate the rule against attributes ({"subject": ..., "resource": ..., "env": ...}), rule
+// must result in boolean
+func (e PolicyExpression) Evaluate(attributes map[string]interface{}) (bool, error) {
+	value, err := e.root.eval(attributes)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression result is %v, not boolean", value)
+	}
+	return result, nil
+}
+
+func (n policyLiteral) eval(map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+// Missing attribute is null so rule can check it
+func (n policyPath) eval(attributes map[string]interface{}) (interface{}, error) {
+	var current interface{} = attributes
+	for _, key := range n.path {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		current = object[key]
+	}
+	return normalizePolicyValue(current), nil
+}
+
+func (n policyList) eval(attributes map[string]interface{}) (interface{}, error) {
+	values := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		value, err := item.eval(attributes)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func (n policyUnary) eval(attributes map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(attributes)
+	if err != nil {
+		return nil, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("not require boolean, got %v", value)
+	}
+	return !result, nil
+}
+
+func (n policyBinary) eval(attributes map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	// and / or short circuit
+	if n.operator == "and" || n.operator == "or" {
+		leftBool, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s require boolean, got %v", n.operator, left)
+		}
+		if (n.operator == "and" && !leftBool) || (n.operator == "or" && leftBool) {
+			return leftBool, nil
+		}
+		right, err := n.right.eval(attributes)
+		if err != nil {
+			return nil, err
+		}
+		rightBool, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s require boolean, got %v", n.operator, right)
+		}
+		return rightBool, nil
+	}
+
+	right, err := n.right.eval(attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.operator {
+	case "==":
+		return policyEqual(left, right), nil
+	case "!=":
+		return !policyEqual(left, right), nil
+	case "in":
+		switch container := right.(type) {
+		case []interface{}:
+			for _, item := range container {
+				if policyEqual(left, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case string:
+			text, ok := left.(string)
+			return ok && strings.Contains(container, text), nil
+		case nil:
+			return false, nil
+		}
+		return nil, fmt.Errorf("in require list or string, got %v", right)
+	}
+
+	// Ordering work on number and string (e.g. date "2006-01-02" or time "15:04")
+	if leftNumber, ok := left.(float64); ok {
+		if rightNumber, ok := right.(float64); ok {
+			return policyCompare(n.operator, leftNumber < rightNumber, leftNumber == rightNumber), nil
+		}
+	}
+	if leftText, ok := left.(string); ok {
+		if rightText, ok := right.(string); ok {
+			return policyCompare(n.operator, leftText < rightText, leftText == rightText), nil
+		}
+	}
+	return nil, fmt.Errorf("can't compare %v %s %v", left, n.operator, right)
+}
+
+func policyCompare(operator string, less bool, equal bool) bool {
+	switch operator {
+	case "<":
+		return less
+	case "<=":
+		return less || equal
+	case ">":
+		return !less && !equal
+	}
+	return !less
+}
+
+func policyEqual(left interface{}, right interface{}) bool {
+	return reflect.DeepEqual(left, right)
+}
+
+// Convert attribute value into the type used by expression: number become float64 and slice
+// become []interface{}
+func normalizePolicyValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(reflected.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(reflected.Uint())
+	case reflect.Float32, reflect.Float64:
+		return reflected.Float()
+	case reflect.Slice, reflect.Array:
+		values := make([]interface{}, reflected.Len())
+		for i := range values {
+			values[i] = normalizePolicyValue(reflected.Index(i).Interface())
+		}
+		return values
+	case reflect.Ptr:
+		if reflected.IsNil() {
+			return nil
+		}
+		return normalizePolicyValue(reflected.Elem().Interface())
+	}
+	return value
+}
@@ -0,0 +1,117 @@
+package helpers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitResult is state of a bucket after a request is taken from it
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until next request is allowed, only set when not allowed
+}
+
+// RateLimitStore keep token bucket per key. Memory store only work for single instance,
+// shared backend (e.g. redis) implement this interface so every instance use the same bucket.
+type RateLimitStore interface {
+	Take(key string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+type tokenBucket struct {
+	tokens    float64
+	window    time.Duration
+	updatedAt time.Time
+}
+
+// MemoryRateLimitStore is token bucket store kept in process memory. Bucket hold at most
+// limit tokens and refill limit tokens per window.
+type MemoryRateLimitStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*tokenBucket
+	cleanedAt time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]*tokenBucket{}, cleanedAt: time.Now()}
+}
+
+func (s *MemoryRateLimitStore) Take(key string, limit int, window time.Duration) (RateLimitResult, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	s.cleanup(now)
+
+	rate := float64(limit) / window.Seconds()
+	bucket, exist := s.buckets[key]
+	if !exist {
+		bucket = &tokenBucket{tokens: float64(limit), window: window, updatedAt: now}
+		s.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(limit), bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*rate)
+	bucket.updatedAt = now
+
+	result := RateLimitResult{Limit: limit}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+	}
+	result.Remaining = int(math.Floor(bucket.tokens))
+	result.Reset = time.Duration((float64(limit) - bucket.tokens) / rate * float64(time.Second))
+
+	return result, nil
+}
+
+// Remove bucket that already refilled, it is the same as new bucket. Run at most once a minute.
+func (s *MemoryRateLimitStore) cleanup(now time.Time) {
+	if now.Sub(s.cleanedAt) < time.Minute {
+		return
+	}
+	s.cleanedAt = now
+
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.updatedAt) > bucket.window {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Parse rate limit from "<limit>/<window>" format, e.g. "10/1m" or "300/1h"
+func ParseRateLimit(value string) (int, time.Duration, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid rate limit %q", value)
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || limit <= 0 {
+		return 0, 0, fmt.Errorf("invalid rate limit %q", value)
+	}
+	window, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || window <= 0 {
+		return 0, 0, fmt.Errorf("invalid rate limit %q", value)
+	}
+
+	return limit, window, nil
+}
+
+// Get rate limit from env in "<limit>/<window>" format, default value is used when the env
+// attribute empty, doesn't exist or invalid
+func GetENVRateLimitWithDefault(key string, defaultLimit int, defaultWindow time.Duration) (int, time.Duration) {
+	limit, window, err := ParseRateLimit(GetENVWithDefault(key, ""))
+	if err != nil {
+		return defaultLimit, defaultWindow
+	}
+
+	return limit, window
+}
@@ -0,0 +1,81 @@
+package helpers
+
+import (
+	"jxb-eprocurement/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Add access token id (jti) into revocation list, expired entries are cleaned up on the way
+func RevokeToken(db *gorm.DB, tokenID string, userID uint, expiresAt time.Time, reason string) error {
+	if tokenID == "" || expiresAt.Before(time.Now()) {
+		return nil
+	}
+
+	db.Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.USR_RevokedToken{})
+
+	revokedToken := models.USR_RevokedToken{
+		TokenID:   tokenID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		Reason:    reason,
+	}
+
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&revokedToken).Error
+}
+
+// Revoke single use token (e.g. challenge token) as the guard of the action using it, run it in
+// the action transaction. False is returned when the token is already revoked, concurrent
+// request with the same token wait on the unique index and get false once the first one commit.
+func ConsumeToken(db *gorm.DB, tokenID string, userID uint, expiresAt time.Time, reason string) (bool, error) {
+	if tokenID == "" {
+		return false, nil
+	}
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.USR_RevokedToken{
+		TokenID:   tokenID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		Reason:    reason,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected != 0, nil
+}
+
+// Check whether access token id (jti) exist in revocation list
+func IsTokenRevoked(db *gorm.DB, tokenID string) (bool, error) {
+	var count int64
+	if err := db.Model(&models.USR_RevokedToken{}).Where("token_id = ?", tokenID).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// Revoke every active session of a user and every access token issued from those sessions
+// that not expired yet, so user tokens stop working immediately
+func RevokeUserTokens(db *gorm.DB, userID uint, reason string) error {
+	now := time.Now()
+
+	var refreshTokens []models.USR_RefreshToken
+	err := db.Joins("JOIN usr_sessions ON usr_sessions.id = usr_refresh_tokens.session_id").
+		Where("usr_sessions.user_id = ? AND usr_refresh_tokens.access_token_expires_at > ?", userID, now).
+		Find(&refreshTokens).Error
+	if err != nil {
+		return err
+	}
+
+	for _, refreshToken := range refreshTokens {
+		if err := RevokeToken(db, refreshToken.AccessTokenID, userID, refreshToken.AccessTokenExpiresAt, reason); err != nil {
+			return err
+		}
+	}
+
+	return db.Model(&models.USR_Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": reason}).Error
+}
@@ -0,0 +1,172 @@
+package helpers
+
+import (
+	"fmt"
+	"jxb-eprocurement/models"
+
+	"gorm.io/gorm"
+)
+
+// Source of a feature held by role
+const (
+	GrantSourceFeature    = "feature"     // Feature granted directly to the role
+	GrantSourceModule     = "module"      // Feature of module (or its descendant) granted to the role
+	GrantSourceParentRole = "parent_role" // Feature inherited from parent role
+)
+
+// RoleFeatureGrant is a feature held by role and where it come from. GrantedBy is the module
+// or parent role that grant the feature, empty for feature granted directly.
+type RoleFeatureGrant struct {
+	Feature     *models.USR_Feature
+	Source      string
+	GrantedByID uint
+	GrantedBy   string
+}
+
+// Load every role with its features and granted modules keyed by id, role table is small so
+// the whole hierarchy is loaded at once instead of walking it one query per level
+func loadRoleHierarchy(db *gorm.DB) (map[uint]models.USR_Role, error) {
+	var roles []models.USR_Role
+	if err := db.Preload("Features").Preload("Modules").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	hierarchy := make(map[uint]models.USR_Role, len(roles))
+	for _, role := range roles {
+		hierarchy[role.ID] = role
+	}
+	return hierarchy, nil
+}
+
+// Get ancestors of the role from the nearest parent, stop on missing (deleted) parent or cycle
+func roleAncestors(hierarchy map[uint]models.USR_Role, parentID *uint) []models.USR_Role {
+	ancestors := []models.USR_Role{}
+	visited := map[uint]struct{}{}
+	for parentID != nil {
+		parent, exist := hierarchy[*parentID]
+		if _, seen := visited[*parentID]; !exist || seen {
+			break
+		}
+		visited[parent.ID] = struct{}{}
+		ancestors = append(ancestors, parent)
+		parentID = parent.ParentID
+	}
+	return ancestors
+}
+
+// Resolve every feature of the role, direct features first then features of granted modules
+// then features of parent roles. Feature held through more than one way keep the first source.
+func roleFeatureGrants(hierarchy map[uint]models.USR_Role, tree moduleTree, role models.USR_Role) []RoleFeatureGrant {
+	grants := []RoleFeatureGrant{}
+	seen := map[uint]struct{}{}
+	add := func(feature *models.USR_Feature, source string, id uint, name string) {
+		if _, exist := seen[feature.ID]; !exist {
+			seen[feature.ID] = struct{}{}
+			grants = append(grants, RoleFeatureGrant{Feature: feature, Source: source, GrantedByID: id, GrantedBy: name})
+		}
+	}
+
+	// Stored role is used since role loaded by caller may not preload its grants
+	if stored, exist := hierarchy[role.ID]; exist {
+		role = stored
+	}
+
+	for _, feature := range role.Features {
+		add(feature, GrantSourceFeature, 0, "")
+	}
+	for _, module := range role.Modules {
+		for _, feature := range tree.features(module.ID) {
+			add(feature, GrantSourceModule, module.ID, module.Name)
+		}
+	}
+	for _, ancestor := range roleAncestors(hierarchy, role.ParentID) {
+		for _, feature := range ancestor.Features {
+			add(feature, GrantSourceParentRole, ancestor.ID, ancestor.Name)
+		}
+		for _, module := range ancestor.Modules {
+			for _, feature := range tree.features(module.ID) {
+				add(feature, GrantSourceParentRole, ancestor.ID, ancestor.Name)
+			}
+		}
+	}
+	return grants
+}
+
+// GetRoleFeatureGrants resolve every feature held by the role with the source of the grant.
+// Preload passed in db (e.g. Features.Module) is applied to features of parent roles as well.
+func GetRoleFeatureGrants(db *gorm.DB, role models.USR_Role) ([]RoleFeatureGrant, error) {
+	hierarchy, err := loadRoleHierarchy(db)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := loadModuleTree(db)
+	if err != nil {
+		return nil, err
+	}
+	return roleFeatureGrants(hierarchy, tree, role), nil
+}
+
+// InheritRoleFeatures replace features of the roles with the effective features, own features
+// plus features of granted modules and every parent role up the hierarchy. Roles must not be
+// saved afterward.
+func InheritRoleFeatures(db *gorm.DB, roles ...*models.USR_Role) error {
+	hierarchy, err := loadRoleHierarchy(db)
+	if err != nil {
+		return err
+	}
+	tree, err := loadModuleTree(db)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		if role.ID == 0 {
+			continue
+		}
+		grants := roleFeatureGrants(hierarchy, tree, *role)
+		features := make([]*models.USR_Feature, len(grants))
+		for i, grant := range grants {
+			features[i] = grant.Feature
+		}
+		role.Features = features
+	}
+	return nil
+}
+
+// InheritUserRoleFeatures apply module grants and role inheritance to primary role and every
+// assigned role of the user loaded by PreloadUserRoles
+func InheritUserRoleFeatures(db *gorm.DB, user *models.USR_User) error {
+	roles := []*models.USR_Role{&user.Role}
+	roles = append(roles, user.Roles...)
+	return InheritRoleFeatures(db, roles...)
+}
+
+// ValidateRoleParent check parent role exist and setting it doesn't make the role inherit from
+// itself. roleID is 0 for new role.
+func ValidateRoleParent(db *gorm.DB, roleID uint, parentID uint) error {
+	if roleID != 0 && roleID == parentID {
+		return fmt.Errorf("Role can't be parent of itself")
+	}
+
+	var roles []models.USR_Role
+	if err := db.Select("id", "name", "parent_id").Find(&roles).Error; err != nil {
+		return err
+	}
+	hierarchy := make(map[uint]models.USR_Role, len(roles))
+	for _, role := range roles {
+		hierarchy[role.ID] = role
+	}
+
+	parent, exist := hierarchy[parentID]
+	if !exist {
+		return fmt.Errorf("Parent role with id %d not found", parentID)
+	}
+
+	// Reaching the role itself while walking up from the new parent means cycle
+	for _, ancestor := range roleAncestors(hierarchy, parent.ParentID) {
+		if ancestor.ID == roleID {
+			return fmt.Errorf("Role %s inherit from this role, inheritance can't be cyclic", parent.Name)
+		}
+	}
+	return nil
+}
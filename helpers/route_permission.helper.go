@@ -0,0 +1,200 @@
+package helpers
+
+import (
+	"jxb-eprocurement/models"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// RoutePermission is the features required by a route, registered together with the route so
+// the route is the single source of the permission it checks
+type RoutePermission struct {
+	Method      string
+	Path        string
+	Features    []string
+	IsAdminOnly bool
+}
+
+var routePermissions = struct {
+	sync.Mutex
+	routes []RoutePermission
+}{}
+
+// Record required features of a route
+func RegisterRoutePermission(permission RoutePermission) {
+	routePermissions.Lock()
+	defer routePermissions.Unlock()
+
+	permission.Features = append([]string{}, permission.Features...)
+	routePermissions.routes = append(routePermissions.routes, permission)
+}
+
+// Get every registered route sorted by path then method
+func GetRoutePermissions() []RoutePermission {
+	routePermissions.Lock()
+	defer routePermissions.Unlock()
+
+	routes := append([]RoutePermission{}, routePermissions.routes...)
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// FindRoutePermission get registered route matching the method and path. Path could be the
+// registered one (/users/:id) or the real one (/users/12), params of the real path are returned.
+// Like gin, static segment take precedence over param, so /users/me match before /users/:id.
+func FindRoutePermission(method string, path string) (RoutePermission, map[string]string, bool) {
+	segments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+
+	var (
+		found       RoutePermission
+		foundParams map[string]string
+		bestStatic  = -1
+	)
+	for _, route := range GetRoutePermissions() {
+		routeSegments := strings.Split(route.Path, "/")
+		if !strings.EqualFold(route.Method, method) || len(routeSegments) != len(segments) {
+			continue
+		}
+
+		params := map[string]string{}
+		static := 0
+		matched := true
+		for i, segment := range routeSegments {
+			switch {
+			case segment == segments[i]:
+				static++
+			case strings.HasPrefix(segment, ":"):
+				params[segment[1:]] = segments[i]
+			default:
+				matched = false
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched && static > bestStatic {
+			found, foundParams, bestStatic = route, params, static
+		}
+	}
+	return found, foundParams, bestStatic >= 0
+}
+
+// IsFeatureAdminOnly is true when every route that require the feature is admin only, so the
+// feature is usable by non admin as long as one of its routes is
+func IsFeatureAdminOnly(code string) bool {
+	used := false
+	for _, route := range GetRoutePermissions() {
+		for _, feature := range route.Features {
+			if feature != code {
+				continue
+			}
+			if !route.IsAdminOnly {
+				return false
+			}
+			used = true
+		}
+	}
+	return used
+}
+
+// Get distinct feature codes referenced by registered routes
+func GetRouteFeatureCodes() []string {
+	codes := []string{}
+	seen := map[string]struct{}{}
+	for _, route := range GetRoutePermissions() {
+		for _, code := range route.Features {
+			if _, exist := seen[code]; !exist {
+				seen[code] = struct{}{}
+				codes = append(codes, code)
+			}
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Get feature codes referenced by routes that don't exist in features table
+func GetMissingRouteFeatures(db *gorm.DB) ([]string, error) {
+	codes := GetRouteFeatureCodes()
+
+	var existing []string
+	if err := db.Model(&models.USR_Feature{}).Where("code IN ?", codes).Pluck("code", &existing).Error; err != nil {
+		return nil, err
+	}
+	existMap := map[string]struct{}{}
+	for _, code := range existing {
+		existMap[code] = struct{}{}
+	}
+
+	missing := []string{}
+	for _, code := range codes {
+		if _, exist := existMap[code]; !exist {
+			missing = append(missing, code)
+		}
+	}
+	return missing, nil
+}
+
+// Convert "change_password" into "Change Password"
+func featureCodeLabel(code string) string {
+	words := strings.Split(code, "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// CreateRouteFeatures create feature for each code under the module matching the code prefix
+// (user.reset_password go to module "User"), code without matching module is skipped
+func CreateRouteFeatures(db *gorm.DB, codes []string) error {
+	var modules []models.USR_Module
+	if err := db.Find(&modules).Error; err != nil {
+		return err
+	}
+	modulePrefix := map[string]models.USR_Module{}
+	for _, module := range modules {
+		modulePrefix[strings.Join(codeWords(module.Name), "_")] = module
+	}
+
+	for _, code := range codes {
+		parts := strings.SplitN(code, ".", 2)
+		module, exist := modulePrefix[parts[0]]
+		if !exist || len(parts) < 2 {
+			log.Printf("Feature %s used by routes is not created, no module for %s", code, parts[0])
+			continue
+		}
+
+		feature := models.USR_Feature{Code: code, Name: featureCodeLabel(strings.ReplaceAll(parts[1], ".", "_")) + " " + module.Name, ModuleID: module.ID}
+		if err := db.Create(&feature).Error; err != nil {
+			log.Printf("Failed to create feature %s: %v", code, err)
+			continue
+		}
+		log.Printf("Feature %s used by routes created", code)
+	}
+	return nil
+}
+
+// SyncRoutePermissions check every feature referenced by routes exist, called on startup after
+// the seeder created the missing ones. Feature still missing (deleted, or no module for its code)
+// is only reported.
+func SyncRoutePermissions(db *gorm.DB) {
+	missing, err := GetMissingRouteFeatures(db)
+	if err != nil {
+		log.Printf("Failed to check route permissions: %v", err)
+		return
+	}
+	if len(missing) != 0 {
+		log.Printf("Features used by routes but not found: %s", strings.Join(missing, ", "))
+	}
+}
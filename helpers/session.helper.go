@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"errors"
+	"jxb-eprocurement/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrSessionInactive = errors.New("session revoked or expired")
+
+// CheckSession make sure session of access token still active and record its last activity.
+// Activity is written at most once per SESSION_ACTIVITY_INTERVAL seconds to keep request cheap.
+func CheckSession(db *gorm.DB, sessionID uint) error {
+	var session models.USR_Session
+	result := db.Limit(1).Where("id = ?", sessionID).Find(&session)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	now := time.Now()
+	if result.RowsAffected == 0 || session.RevokedAt != nil || session.ExpiresAt.Before(now) {
+		return ErrSessionInactive
+	}
+
+	interval := time.Duration(GetENVIntWithDefault("SESSION_ACTIVITY_INTERVAL", 60)) * time.Second
+	if session.LastActivityAt == nil || now.Sub(*session.LastActivityAt) > interval {
+		db.Model(&models.USR_Session{}).Where("id = ?", session.ID).UpdateColumn("last_activity_at", now)
+	}
+
+	return nil
+}
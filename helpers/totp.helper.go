@@ -0,0 +1,108 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpDigits = 6
+	totpPeriod = 30
+)
+
+// Generate random base32 secret (160 bit) for TOTP enrollment
+func GenerateTOTPSecret() (string, error) {
+	buffer := make([]byte, 20)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buffer), nil
+}
+
+// Build otpauth uri that could be rendered as qr code by authenticator app
+func GenerateTOTPURI(secret string, accountName string) string {
+	issuer := GetENVWithDefault("APP_NAME", "E-Procurement")
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(fmt.Sprintf("%s:%s", issuer, accountName))
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// Generate HOTP code (RFC 4226) for the given counter
+func generateHOTP(secret string, counter int64) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, code%1000000), nil
+}
+
+// ValidateTOTP check TOTP code (RFC 6238) allowing one step clock drift. The matched time step
+// is returned and must be greater than lastStep, so the same code can't be used twice.
+func ValidateTOTP(secret string, code string, at time.Time, lastStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	currentStep := at.Unix() / totpPeriod
+
+	for _, step := range []int64{currentStep - 1, currentStep, currentStep + 1} {
+		if step <= lastStep {
+			continue
+		}
+
+		expected, err := generateHOTP(secret, step)
+		if err != nil {
+			return 0, false
+		}
+
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// Generate single-use recovery codes formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, count)
+	for i := range codes {
+		buffer := make([]byte, 7)
+		if _, err := rand.Read(buffer); err != nil {
+			return nil, err
+		}
+
+		encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buffer))
+		codes[i] = fmt.Sprintf("%s-%s", encoded[:5], encoded[5:10])
+	}
+
+	return codes, nil
+}
+
+// Normalize recovery code before hashing so formatting typed by user doesn't matter
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"jxb-eprocurement/models"
+
+	"gorm.io/gorm"
+)
+
+// PreloadUserRoles preload primary role and every assigned role of user with their features
+func PreloadUserRoles(db *gorm.DB) *gorm.DB {
+	return db.Preload("Role").Preload("Role.Features").Preload("Roles").Preload("Roles.Features")
+}
+
+// UserFeatureCodes return union of feature codes of every role of the user
+func UserFeatureCodes(user models.USR_User) []string {
+	features := []string{}
+	seen := map[string]struct{}{}
+	for _, role := range user.EffectiveRoles() {
+		for _, feature := range role.Features {
+			if _, exist := seen[feature.Code]; !exist {
+				seen[feature.Code] = struct{}{}
+				features = append(features, feature.Code)
+			}
+		}
+	}
+	return features
+}
+
+// IsUserAdministrative is true when any role of the user is administrative
+func IsUserAdministrative(user models.USR_User) bool {
+	for _, role := range user.EffectiveRoles() {
+		if role.IsAdministrative {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTwoFactorRequired is true when any role of the user require two factor
+func IsTwoFactorRequired(user models.USR_User) bool {
+	for _, role := range user.EffectiveRoles() {
+		if role.RequireTwoFactor {
+			return true
+		}
+	}
+	return false
+}
+
+// UserRoleIDs return id of every role of the user, primary role first
+func UserRoleIDs(user models.USR_User) []uint {
+	roles := user.EffectiveRoles()
+	ids := make([]uint, len(roles))
+	for i, role := range roles {
+		ids[i] = role.ID
+	}
+	return ids
+}
+
+// UserRoleNames return name of every role of the user, primary role first
+func UserRoleNames(user models.USR_User) []string {
+	roles := user.EffectiveRoles()
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = role.Name
+	}
+	return names
+}
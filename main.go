@@ -5,6 +5,7 @@ import (
 	"jxb-eprocurement/config"
 	"jxb-eprocurement/database"
 	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/helpers"
 	"jxb-eprocurement/middlewares"
 	"jxb-eprocurement/models"
 	"jxb-eprocurement/routers"
@@ -35,6 +36,11 @@ func main() {
 		log.Fatalf("Error loading .env file")
 	}
 
+	// Load jwt signing and verification keys
+	if err := helpers.LoadJWTKeys(); err != nil {
+		log.Fatalf("Failed to load jwt keys: %v", err)
+	}
+
 	// Setup database connection
 	db, err := config.SetupDatabase()
 	if err != nil {
@@ -44,11 +50,14 @@ func main() {
 	// Initialize DB in models
 	models.InitDB(db)
 
+	// Setup router, routes register the features they require which are seeded by migrations
+	router := routers.SetupRouter(db)
+
 	// Run migrations
 	database.Migrate(db)
 
-	// Setup router
-	router := routers.SetupRouter(db)
+	// Every feature required by the registered routes must exist
+	helpers.SyncRoutePermissions(db)
 
 	// Apply the RequestID middleware
 	router.Use(middlewares.RequestIDMiddleware())
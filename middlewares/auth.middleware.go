@@ -1,20 +1,64 @@
 package middlewares
 
 import (
+	"fmt"
 	"jxb-eprocurement/handlers"
 	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/helpers"
 	"jxb-eprocurement/models"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// Middleware to check user jwt is valid and correct
+// Authenticate service account by api key, the key features are kept in context so
+// authorization only allow features that both the key and the account role have
+func apiKeyAuthentication(c *gin.Context, key string) {
+	apiKey, err := helpers.AuthenticateAPIKey(models.DB, key)
+	if err == helpers.ErrInvalidAPIKey {
+		handlers.ResponseFormatter(c, http.StatusUnauthorized, nil, "Invalid or expired API key")
+		c.Abort()
+		return
+	}
+	if err != nil {
+		handlers.ResponseFormatter(c, http.StatusInternalServerError, nil, "Failed to check API key")
+		c.Abort()
+		return
+	}
+
+	permission, err := helpers.ResolveUserPermission(models.DB, apiKey.UserID)
+	if err != nil {
+		handlers.ResponseFormatter(c, http.StatusUnauthorized, nil, "Invalid or expired API key")
+		c.Abort()
+		return
+	}
+
+	keyFeatures := make([]string, len(apiKey.Features))
+	for i, feature := range apiKey.Features {
+		keyFeatures[i] = feature.Code
+	}
+
+	c.Set("api_key_features", keyFeatures)
+	c.Set("user", &models.USR_User{ID: apiKey.User.ID, Name: apiKey.User.Name, RoleID: permission.RoleID})
+	c.Set("role", &models.USR_Role{ID: permission.RoleID, Name: permission.Role, IsAdministrative: permission.IsAdministrative})
+	c.Set("features", helpers.IntersectFeatures(permission.Features, keyFeatures))
+	c.Set("UserID", fmt.Sprint(apiKey.User.ID))
+	c.Set("username", apiKey.User.Username)
+
+	c.Next()
+}
+
+// Middleware to check user jwt (or service account api key) is valid and correct
 func Authentication() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			apiKeyAuthentication(c, key)
+			return
+		}
+
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" || !strings.HasPrefix(tokenString, "Bearer ") {
 			handlers.ResponseFormatter(c, http.StatusUnauthorized, nil, "Authorization token not provided")
@@ -25,7 +69,7 @@ func Authentication() gin.HandlerFunc {
 		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
 		claims := &dtos.Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) { return []byte(os.Getenv("JWT_SECRET")), nil })
+		token, err := helpers.ParseJWT(tokenString, claims)
 
 		if err != nil || !token.Valid {
 			if err == jwt.ErrSignatureInvalid {
@@ -52,66 +96,166 @@ func Authentication() gin.HandlerFunc {
 			return
 		}
 
+		// Token without id cannot be revoked and token without session is not an access token
+		// (e.g. challenge token), only access token issued with jti and sid is accepted
+		if claims.Id == "" || claims.SessionID == 0 {
+			handlers.ResponseFormatter(c, http.StatusUnauthorized, nil, "Invalid token")
+			c.Abort()
+			return
+		}
+
+		// Check token against revocation list
+		revoked, err := helpers.IsTokenRevoked(models.DB, claims.Id)
+		if err != nil {
+			handlers.ResponseFormatter(c, http.StatusInternalServerError, nil, "Failed to check token revocation")
+			c.Abort()
+			return
+		}
+		if revoked {
+			handlers.ResponseFormatter(c, http.StatusUnauthorized, nil, "Token has been revoked")
+			c.Abort()
+			return
+		}
+
+		// Check session of the token, revoked session sign out every token of that login
+		if err := helpers.CheckSession(models.DB, claims.SessionID); err != nil {
+			if err == helpers.ErrSessionInactive {
+				handlers.ResponseFormatter(c, http.StatusUnauthorized, nil, "Session has been revoked")
+			} else {
+				handlers.ResponseFormatter(c, http.StatusInternalServerError, nil, "Failed to check session")
+			}
+			c.Abort()
+			return
+		}
+
 		// Parse claims data to context for further access authorization
+		c.Set("claims", claims)
 		c.Set("user", &models.USR_User{ID: claims.UserID, Name: claims.User, RoleID: claims.RoleID})
 		c.Set("role", &models.USR_Role{ID: claims.RoleID, Name: claims.Role, IsAdministrative: claims.IsAdministrative})
 		c.Set("features", claims.Features)
+		c.Set("UserID", fmt.Sprint(claims.UserID))
+		c.Set("username", claims.Username)
+		if claims.Act != nil {
+			c.Set("impersonator_id", fmt.Sprint(claims.Act.UserID))
+			c.Set("impersonator_username", claims.Act.Username)
+		}
 
 		c.Next()
 	}
 }
 
-// Middleware to check user have access (feature) to access the endpoint
-// TODO: After Vendor Module Finish Development, Adding Check if Vendor Is Validated Or Not
-func Authorization(allowedFeatures []string, isAdminOnly bool) gin.HandlerFunc {
+// Middleware for two factor enrollment endpoint. Beside access token it accept enrollment
+// challenge given by login to user whose role require two factor but not enrolled yet.
+func TwoFactorEnrollmentAuthentication() gin.HandlerFunc {
+	authentication := Authentication()
 	return func(c *gin.Context) {
-		// Only admin can use this resource
-		if isAdminOnly {
-			role, exist := c.Get("role")
-			if !exist {
-				handlers.ResponseFormatter(c, http.StatusForbidden, nil, "Unauthorized to access this resource")
-				c.Abort()
-				return
-			} else {
-				roleData, ok := role.(*models.USR_Role)
-				if !ok || !roleData.IsAdministrative {
-					handlers.ResponseFormatter(c, http.StatusForbidden, nil, "Unauthorized to access this resource")
-					c.Abort()
-					return
-				}
-			}
+		claims, err := helpers.ParseChallengeToken(c.GetHeader("Authorization"), helpers.ChallengePurposeTwoFactorEnrollment)
+		if err != nil {
+			authentication(c)
+			return
 		}
 
-		features, exist := c.Get("features")
-		if !exist {
-			handlers.ResponseFormatter(c, http.StatusForbidden, nil, "Unauthorized to access this resource")
+		// Challenge token is single use, it is revoked once enrollment completed
+		revoked, err := helpers.IsTokenRevoked(models.DB, claims.Id)
+		if err != nil || revoked {
+			handlers.ResponseFormatter(c, http.StatusUnauthorized, nil, "Token has been revoked")
+			c.Abort()
+			return
+		}
+
+		c.Set("challenge", claims)
+		c.Set("user", &models.USR_User{ID: claims.UserID})
+
+		c.Next()
+	}
+}
+
+// Middleware for account security endpoint (two factor, password, sign out), impersonating
+// admin act on behalf of the user but must not change how the user sign in
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonated := c.Get("impersonator_id"); impersonated {
+			handlers.ResponseFormatter(c, http.StatusForbidden, nil, "Not allowed while impersonating user")
 			c.Abort()
 			return
 		}
 
-		// Assert that features is a slice of strings
-		featureList, ok := features.([]string)
-		if !ok {
+		c.Next()
+	}
+}
+
+// Middleware to check user have access (feature) to access the endpoint.
+// Role and features are resolved from database (cached) on every request instead of trusting
+// the token, so permission changes take effect on the next request. User that is not active
+// (e.g. vendor waiting for validation) can't access any resource.
+func Authorization(allowedFeatures []string, isAdminOnly bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.USR_User
+		helpers.GetUserPayload(c, &user)
+		if user.ID == 0 {
 			handlers.ResponseFormatter(c, http.StatusForbidden, nil, "Unauthorized to access this resource")
 			c.Abort()
 			return
 		}
 
-		// Convert allowed features to a map for efficient lookup
-		allowedFeaturesMap := make(map[string]struct{})
-		for _, feature := range allowedFeatures {
-			allowedFeaturesMap[feature] = struct{}{}
+		// Resolve current role and features of the user
+		permission, err := helpers.ResolveUserPermission(models.DB, user.ID)
+		if err == gorm.ErrRecordNotFound {
+			handlers.ResponseFormatter(c, http.StatusUnauthorized, nil, "User no longer exist")
+			c.Abort()
+			return
+		}
+		if err != nil {
+			handlers.ResponseFormatter(c, http.StatusInternalServerError, nil, "Failed to resolve user permission")
+			c.Abort()
+			return
 		}
 
-		// Check if user have allowed list
-		for _, feature := range featureList {
-			if _, exists := allowedFeaturesMap[feature]; exists {
-				c.Next()
-				return
-			}
+		// API key can only use the features granted to the key
+		features := helpers.RequestFeatures(c, permission)
+
+		// Replace token payload with the current one for further use in service
+		user.RoleID = permission.RoleID
+		c.Set("user", &user)
+		c.Set("role", &models.USR_Role{ID: permission.RoleID, Name: permission.Role, IsAdministrative: permission.IsAdministrative})
+		c.Set("features", features)
+
+		// Active status, admin only flag, allowed features and policies are checked the same way
+		// as permission check endpoint
+		decision, err := helpers.DecideAccess(models.DB, c, permission, features, helpers.AccessRequest{
+			AllowedFeatures: allowedFeatures,
+			IsAdminOnly:     isAdminOnly,
+			ResourceID:      c.Param("id"),
+			Method:          c.Request.Method,
+			Path:            c.FullPath(),
+		})
+		if err != nil {
+			handlers.ResponseFormatter(c, http.StatusInternalServerError, nil, "Failed to evaluate access policy")
+			c.Abort()
+			return
+		}
+		if !decision.Allowed {
+			handlers.ResponseFormatter(c, http.StatusForbidden, nil, decision.Reason)
+			c.Abort()
+			return
 		}
 
-		handlers.ResponseFormatter(c, http.StatusForbidden, nil, "Unauthorized to access this resource")
-		c.Abort()
+		// Rows the user can access is decided by the roles that grant the features
+		c.Set("data_scope", decision.DataScope)
+
+		c.Next()
 	}
 }
+
+// HandleWithPermission register route protected by Authorization and record the required
+// features in route permission registry, so the features can be checked against database
+func HandleWithPermission(group *gin.RouterGroup, method string, path string, allowedFeatures []string, isAdminOnly bool, handler ...gin.HandlerFunc) {
+	helpers.RegisterRoutePermission(helpers.RoutePermission{
+		Method:      method,
+		Path:        strings.TrimSuffix(group.BasePath()+path, "/"),
+		Features:    allowedFeatures,
+		IsAdminOnly: isAdminOnly,
+	})
+
+	group.Handle(method, path, append([]gin.HandlerFunc{Authorization(allowedFeatures, isAdminOnly)}, handler...)...)
+}
@@ -40,6 +40,13 @@ func CORS() gin.HandlerFunc {
 			"User-Agent",
 			"Host",
 		},
+		ExposeHeaders: []string{
+			"RateLimit-Limit",
+			"RateLimit-Remaining",
+			"RateLimit-Reset",
+			"RateLimit-Policy",
+			"Retry-After",
+		},
 		AllowCredentials: true,
 		MaxAge:           time.Duration(maxAge) * time.Hour,
 	}
@@ -0,0 +1,147 @@
+package middlewares
+
+import (
+	"fmt"
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitKeyFunc decide which bucket a request belong to
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// RateLimitPolicy is allowed amount of request per window for a route group, configured
+// with RATE_LIMIT_<NAME> env in "<limit>/<window>" format (e.g. "10/1m")
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Key    RateLimitKeyFunc
+}
+
+// Store used by every rate limit middleware, replace it with shared store when running
+// more than one instance
+var rateLimitStore helpers.RateLimitStore = helpers.NewMemoryRateLimitStore()
+
+func SetRateLimitStore(store helpers.RateLimitStore) {
+	rateLimitStore = store
+}
+
+// Bucket per client ip
+func RateLimitByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// Bucket per authenticated user, fallback to client ip. Must run after Authentication.
+func RateLimitByUser(c *gin.Context) string {
+	var user models.USR_User
+	helpers.GetUserPayload(c, &user)
+	if user.ID == 0 {
+		return RateLimitByIP(c)
+	}
+
+	return fmt.Sprintf("user:%d", user.ID)
+}
+
+// Bucket per api key so every key of a service account get its own quota, fallback to user
+func RateLimitByAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return "api_key:" + helpers.HashToken(key)
+	}
+
+	return RateLimitByUser(c)
+}
+
+// Create rate limit policy, limit and window from RATE_LIMIT_<NAME> env take precedence
+func NewRateLimitPolicy(name string, defaultLimit int, defaultWindow time.Duration, key RateLimitKeyFunc) RateLimitPolicy {
+	limit, window := helpers.GetENVRateLimitWithDefault("RATE_LIMIT_"+strings.ToUpper(name), defaultLimit, defaultWindow)
+	return RateLimitPolicy{Name: name, Limit: limit, Window: window, Key: key}
+}
+
+// Strict policy for login endpoints, against password guessing from single ip
+func LoginRateLimitPolicy() RateLimitPolicy {
+	return NewRateLimitPolicy("login", 10, time.Minute, RateLimitByIP)
+}
+
+// Policy for other public authentication endpoints (refresh, register, password reset, etc)
+func AuthRateLimitPolicy() RateLimitPolicy {
+	return NewRateLimitPolicy("auth", 30, time.Minute, RateLimitByIP)
+}
+
+// Policy for authenticated read endpoints
+func ReadRateLimitPolicy() RateLimitPolicy {
+	return NewRateLimitPolicy("read", 300, time.Minute, RateLimitByAPIKey)
+}
+
+// Policy for authenticated write endpoints
+func WriteRateLimitPolicy() RateLimitPolicy {
+	return NewRateLimitPolicy("write", 60, time.Minute, RateLimitByAPIKey)
+}
+
+// Write RateLimit-* header (IETF draft) of the bucket
+func setRateLimitHeaders(c *gin.Context, policy RateLimitPolicy, result helpers.RateLimitResult) {
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+}
+
+// Middleware to limit request rate using token bucket, disabled when RATE_LIMIT_ENABLED is false.
+// Store failure doesn't block the request.
+func RateLimit(policy RateLimitPolicy) gin.HandlerFunc {
+	if !helpers.GetENVBoolWithDefault("RATE_LIMIT_ENABLED", true) {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		result, err := rateLimitStore.Take(policy.Name+":"+policy.Key(c), policy.Limit, policy.Window)
+		if err != nil {
+			handlers.LogSystem(handlers.LogSystemParam{
+				Identifier: c.GetString("X-Request-ID"),
+				StatusCode: http.StatusInternalServerError,
+				Location:   "RateLimit",
+				Message:    "Failed to check rate limit",
+				StartTime:  time.Now(),
+				EndTime:    time.Now(),
+				Err:        err.Error(),
+			})
+			c.Next()
+			return
+		}
+
+		setRateLimitHeaders(c, policy, result)
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			handlers.ResponseFormatter(c, http.StatusTooManyRequests, nil, fmt.Sprintf("Too many requests, try again in %d seconds", retryAfter))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Middleware that use read policy for safe method (GET, HEAD, OPTIONS) and write policy for the rest
+func RateLimitReadWrite(read RateLimitPolicy, write RateLimitPolicy) gin.HandlerFunc {
+	readLimit := RateLimit(read)
+	writeLimit := RateLimit(write)
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			readLimit(c)
+		default:
+			writeLimit(c)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// USR_APIKey is credential of service account for machine to machine access. Only hash of
+// the key is stored, the key can only use features listed here that the account role still has.
+type USR_APIKey struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	UserID     uint           `json:"user_id" gorm:"index"`
+	Name       string         `json:"name"`
+	Prefix     string         `json:"prefix" gorm:"size:16"`
+	KeyHash    string         `json:"-" gorm:"size:64;uniqueIndex"`
+	ExpiresAt  *time.Time     `json:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	RevokedAt  *time.Time     `json:"revoked_at"`
+	User       USR_User       `json:"user" gorm:"foreignKey:UserID"`
+	Features   []*USR_Feature `gorm:"many2many:usr_apikeyfeatures;" json:"features"`
+	gorm.Model
+}
+
+func (USR_APIKey) TableName() string {
+	return "usr_api_keys"
+}
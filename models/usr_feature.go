@@ -7,6 +7,7 @@ import (
 type USR_Feature struct {
 	ID       uint        `json:"id" gorm:"primaryKey" `
 	ModuleID uint        `json:"module_id" validate:"required"`
+	Code     string      `json:"code" gorm:"size:100;uniqueIndex"` // Immutable, used for authorization
 	Name     string      `json:"name" validate:"required"`
 	Module   USR_Module  `json:"module" gorm:"foreignKey:ModuleID"`
 	Roles    []*USR_Role `gorm:"many2many:usr_rolefeatures;" json:"roles"`
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Method used for login attempt
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodTwoFactor = "two_factor"
+	LoginMethodOIDC      = "oidc"
+)
+
+// Result of login attempt
+const (
+	LoginReasonSuccess            = "success"
+	LoginReasonInvalidCredentials = "invalid_credentials"
+	LoginReasonThrottled          = "throttled"
+	LoginReasonInactive           = "inactive"
+	LoginReasonPasswordExpired    = "password_expired"
+	LoginReasonInvalidTwoFactor   = "invalid_two_factor"
+	LoginReasonUnknownIdentity    = "unknown_identity"
+)
+
+// USR_LoginHistory record every login attempt. UserID is empty when the attempt doesn't match
+// any user, Identifier keep what the client entered (username, email or external subject).
+// IsNewDevice flag successful login from ip and user agent never used by the user before.
+type USR_LoginHistory struct {
+	ID          uint     `gorm:"primaryKey" json:"id"`
+	UserID      *uint    `json:"user_id" gorm:"index"`
+	Identifier  string   `json:"identifier" gorm:"size:255;index"`
+	Method      string   `json:"method" gorm:"size:32"`
+	Success     bool     `json:"success"`
+	Reason      string   `json:"reason" gorm:"size:64"`
+	ClientIP    string   `json:"client_ip" gorm:"size:64;index"`
+	UserAgent   string   `json:"user_agent"`
+	IsNewDevice bool     `json:"is_new_device"`
+	User        USR_User `json:"user" gorm:"foreignKey:UserID"`
+	gorm.Model
+}
+
+func (USR_LoginHistory) TableName() string {
+	return "usr_login_histories"
+}
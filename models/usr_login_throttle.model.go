@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// USR_LoginThrottle count failed login attempt per key, key is either
+// "user:<user id>", "account:<hash of unknown username or email>" or "ip:<client ip>"
+type USR_LoginThrottle struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Key           string     `json:"key" gorm:"size:191;uniqueIndex"`
+	Failures      int        `json:"failures"`
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	gorm.Model
+}
+
+func (USR_LoginThrottle) TableName() string {
+	return "usr_login_throttles"
+}
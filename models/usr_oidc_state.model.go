@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// USR_OIDCState keep pending OIDC login between redirect to identity provider and callback,
+// the state is single use and only its hash is stored
+type USR_OIDCState struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	StateHash    string    `json:"-" gorm:"size:64;uniqueIndex"`
+	Nonce        string    `json:"-"`
+	CodeVerifier string    `json:"-"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"index"`
+	gorm.Model
+}
+
+func (USR_OIDCState) TableName() string {
+	return "usr_oidc_states"
+}
@@ -0,0 +1,15 @@
+package models
+
+import "gorm.io/gorm"
+
+// USR_PasswordHistory keep hash of passwords used by user to prevent reusing recent password
+type USR_PasswordHistory struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	UserID       uint   `json:"user_id" gorm:"index"`
+	PasswordHash string `json:"-"`
+	gorm.Model
+}
+
+func (USR_PasswordHistory) TableName() string {
+	return "usr_password_histories"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "gorm.io/gorm"
+
+// Effect of policy rule
+const (
+	PolicyEffectAllow = "allow" // Request is denied unless the expression is true
+	PolicyEffectDeny  = "deny"  // Request is denied when the expression is true
+)
+
+// USR_Policy is attribute based rule evaluated after the feature check passed, it applies to
+// every route that is granted by the feature
+type USR_Policy struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Name        string `json:"name" gorm:"size:100"`
+	Description string `json:"description"`
+	FeatureCode string `json:"feature_code" gorm:"size:100;index"`
+	Effect      string `json:"effect" gorm:"size:16"`
+	Expression  string `json:"expression" gorm:"type:text"`
+	IsActive    bool   `json:"is_active"`
+	gorm.Model
+}
+
+func (USR_Policy) TableName() string {
+	return "usr_policies"
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// USR_RecoveryCode is hashed single-use code to pass two factor authentication
+// when the authenticator app is not available
+type USR_RecoveryCode struct {
+	ID       uint       `gorm:"primaryKey" json:"id"`
+	UserID   uint       `json:"user_id"`
+	CodeHash string     `json:"-" gorm:"size:64;index"`
+	UsedAt   *time.Time `json:"used_at"`
+	gorm.Model
+}
+
+func (USR_RecoveryCode) TableName() string {
+	return "usr_recovery_codes"
+}
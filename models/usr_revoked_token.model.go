@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// USR_RevokedToken is revocation list of access token keyed by token id (jti),
+// entry only needed until the token itself expired
+type USR_RevokedToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TokenID   string    `json:"token_id" gorm:"size:36;uniqueIndex"`
+	UserID    uint      `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	Reason    string    `json:"reason"`
+	gorm.Model
+}
+
+func (USR_RevokedToken) TableName() string {
+	return "usr_revoked_tokens"
+}
@@ -2,11 +2,22 @@ package models
 
 import "gorm.io/gorm"
 
+// Data scope of role, which rows the role can access through its features
+const (
+	DataScopeOwn  = "own"  // Records owned by the user
+	DataScopeUnit = "unit" // Records owned by user of the same organization unit
+	DataScopeAll  = "all"  // Every record
+)
+
 type USR_Role struct {
 	ID               uint           `gorm:"primaryKey" json:"id"`
 	Name             string         `json:"name" validate:"required"`
 	IsAdministrative bool           `json:"is_administrative"`
+	RequireTwoFactor bool           `json:"require_two_factor"`
+	DataScope        string         `json:"data_scope" gorm:"size:16;default:all"`
+	ParentID         *uint          `json:"parent_id" gorm:"index"` // Features of parent role are inherited
 	Features         []*USR_Feature `gorm:"many2many:usr_rolefeatures;" json:"features"`
+	Modules          []*USR_Module  `gorm:"many2many:usr_rolemodules;" json:"modules"` // Every feature of the module and its descendants
 	gorm.Model
 }
 
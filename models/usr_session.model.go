@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// USR_Session represent one login of a user, every refresh token rotated from
+// that login belong to the same session (token family). Session started by impersonation
+// has UserID of the impersonated user and ImpersonatorID of the real user.
+type USR_Session struct {
+	ID             uint               `gorm:"primaryKey" json:"id"`
+	UserID         uint               `json:"user_id"`
+	UserAgent      string             `json:"user_agent"`
+	ClientIP       string             `json:"client_ip"`
+	ExpiresAt      time.Time          `json:"expires_at"`
+	LastActivityAt *time.Time         `json:"last_activity_at"`
+	RevokedAt      *time.Time         `json:"revoked_at"`
+	RevokedReason  string             `json:"revoked_reason"`
+	ImpersonatorID *uint              `json:"impersonator_id" gorm:"index"`
+	User           USR_User           `json:"user" gorm:"foreignKey:UserID"`
+	RefreshTokens  []USR_RefreshToken `json:"refresh_tokens" gorm:"foreignKey:SessionID"`
+	gorm.Model
+}
+
+func (USR_Session) TableName() string {
+	return "usr_sessions"
+}
+
+// USR_RefreshToken store hashed refresh token, the plain token only known by the client.
+// AccessTokenID is the jti of access token issued together with the refresh token,
+// used to revoke outstanding access token of a user.
+type USR_RefreshToken struct {
+	ID                   uint        `gorm:"primaryKey" json:"id"`
+	SessionID            uint        `json:"session_id"`
+	TokenHash            string      `json:"-" gorm:"size:64;uniqueIndex"`
+	ExpiresAt            time.Time   `json:"expires_at"`
+	UsedAt               *time.Time  `json:"used_at"`
+	AccessTokenID        string      `json:"access_token_id" gorm:"size:36;index"`
+	AccessTokenExpiresAt time.Time   `json:"access_token_expires_at"`
+	Session              USR_Session `json:"session" gorm:"foreignKey:SessionID"`
+	gorm.Model
+}
+
+func (USR_RefreshToken) TableName() string {
+	return "usr_refresh_tokens"
+}
@@ -1,18 +1,66 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status of user account, only active user can login
+const (
+	UserStatusPendingVerification = "pending_verification"
+	UserStatusPendingApproval     = "pending_approval"
+	UserStatusActive              = "active"
+)
 
 type USR_User struct {
-	ID       uint     `gorm:"primaryKey" json:"id"`
-	RoleID   uint     `json:"role_id"`
-	Username string   `json:"username" validate:"required,min=3,max=100"`
-	Name     string   `json:"name" validate:"required,min=3,max=100"`
-	Email    string   `json:"email" validate:"required,email"`
-	Password string   `json:"password" validate:"required"`
-	Role     USR_Role `json:"role" gorm:"foreignKey:RoleID"`
+	ID                uint                  `gorm:"primaryKey" json:"id"`
+	RoleID            uint                  `json:"role_id"`
+	Username          string                `json:"username" validate:"required,min=3,max=100"`
+	Name              string                `json:"name" validate:"required,min=3,max=100"`
+	Email             string                `json:"email" validate:"required,email"`
+	OrganizationUnit  string                `json:"organization_unit" gorm:"size:100;index"`
+	Password          string                `json:"password" validate:"required"`
+	PasswordChangedAt *time.Time            `json:"password_changed_at"`
+	IsServiceAccount  bool                  `json:"is_service_account"`
+	Status            string                `json:"status" gorm:"size:32;default:active;index"`
+	EmailVerifiedAt   *time.Time            `json:"email_verified_at"`
+	OIDCSubject       *string               `json:"-" gorm:"size:191;uniqueIndex"`
+	TOTPSecret        string                `json:"-"`
+	TOTPEnabled       bool                  `json:"totp_enabled"`
+	TOTPLastStep      int64                 `json:"-"`
+	Role              USR_Role              `json:"role" gorm:"foreignKey:RoleID"` // Primary role
+	Roles             []*USR_Role           `json:"roles" gorm:"many2many:usr_userroles;"`
+	RecoveryCodes     []USR_RecoveryCode    `json:"-" gorm:"foreignKey:UserID"`
+	PasswordHistories []USR_PasswordHistory `json:"-" gorm:"foreignKey:UserID"`
+	APIKeys           []USR_APIKey          `json:"-" gorm:"foreignKey:UserID"`
 	gorm.Model
 }
 
 func (USR_User) TableName() string {
 	return "usr_users"
 }
+
+// EffectiveRoles return every role of the user, primary role first. Primary role is included
+// even when it is missing from the join table (e.g. data created before multiple roles).
+func (user USR_User) EffectiveRoles() []*USR_Role {
+	roles := []*USR_Role{}
+	seen := map[uint]struct{}{}
+
+	add := func(role *USR_Role) {
+		if role == nil || role.ID == 0 {
+			return
+		}
+		if _, exist := seen[role.ID]; exist {
+			return
+		}
+		seen[role.ID] = struct{}{}
+		roles = append(roles, role)
+	}
+
+	add(&user.Role)
+	for _, role := range user.Roles {
+		add(role)
+	}
+	return roles
+}
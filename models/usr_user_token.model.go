@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Purpose of single-use user token
+const (
+	UserTokenPurposePasswordReset     = "password_reset"
+	UserTokenPurposeEmailVerification = "email_verification"
+)
+
+// USR_UserToken is single-use, time-limited token sent to user out of band (e.g. email),
+// only the hash of the token is stored
+type USR_UserToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	Purpose   string     `json:"purpose" gorm:"size:32;index"`
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	User      USR_User   `json:"user" gorm:"foreignKey:UserID"`
+	gorm.Model
+}
+
+func (USR_UserToken) TableName() string {
+	return "usr_user_tokens"
+}
@@ -12,4 +12,8 @@ func InitAccessRoutes(r *gin.RouterGroup, db *gorm.DB) {
 	InitFeatureRoutes(accessRoutes, db)
 	InitRoleRoutes(accessRoutes, db)
 	InitUserRoutes(accessRoutes, db)
+	InitServiceAccountRoutes(accessRoutes, db)
+	InitLoginHistoryRoutes(accessRoutes, db)
+	InitRoutePermissionRoutes(accessRoutes, db)
+	InitPolicyRoutes(accessRoutes, db)
 }
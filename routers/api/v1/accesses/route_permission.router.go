@@ -0,0 +1,34 @@
+package accesses
+
+import (
+	"jxb-eprocurement/controllers"
+	"jxb-eprocurement/middlewares"
+	"jxb-eprocurement/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func InitRoutePermissionRoutes(r *gin.RouterGroup, db *gorm.DB) {
+	// Setup controller and route
+	routePermissionController := controllers.RoutePermissionControllerConstructor(service.RoutePermissionServiceConstructor(db))
+	routePermissionRoutes := r.Group("/route-permissions")
+
+	// Additional middleware to implement to the group routes
+	routePermissionRoutes.Use(middlewares.Authentication())
+	routePermissionRoutes.Use(middlewares.RateLimitReadWrite(middlewares.ReadRateLimitPolicy(), middlewares.WriteRateLimitPolicy()))
+
+	// Collection of routes
+	{
+		// Get All
+		middlewares.HandleWithPermission(
+			routePermissionRoutes,
+			http.MethodGet,
+			"",
+			[]string{"feature.view"},
+			true,
+			routePermissionController.GetAllRoutePermissions,
+		)
+	}
+}
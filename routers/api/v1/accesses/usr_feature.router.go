@@ -4,6 +4,7 @@ import (
 	"jxb-eprocurement/controllers"
 	"jxb-eprocurement/middlewares"
 	"jxb-eprocurement/service"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,65 +17,67 @@ func InitFeatureRoutes(r *gin.RouterGroup, db *gorm.DB) {
 
 	// Additional middleware to implement to the group routes
 	moduleRoutes.Use(middlewares.Authentication()) // Uncomment this when the user module and feature module is finish
+	moduleRoutes.Use(middlewares.RateLimitReadWrite(middlewares.ReadRateLimitPolicy(), middlewares.WriteRateLimitPolicy()))
 
 	// Collection of routes
 	{
 		// Get All
-		moduleRoutes.GET(
+		middlewares.HandleWithPermission(
+			moduleRoutes,
+			http.MethodGet,
 			"",
-			middlewares.Authorization(
-				[]string{
-					"View Feature",
-					"Create Feature",
-					"Update Feature",
-					"Delete Feature",
-				},
-				false,
-			),
-			featureController.GetAllFeatures)
+			[]string{
+				"feature.view",
+				"feature.create",
+				"feature.update",
+				"feature.delete",
+			},
+			false,
+			featureController.GetAllFeatures,
+		)
 
 		// Get Detail
-		moduleRoutes.GET(
+		middlewares.HandleWithPermission(
+			moduleRoutes,
+			http.MethodGet,
 			"/:id",
-			middlewares.Authorization(
-				[]string{
-					"View Feature",
-					"Create Feature",
-					"Update Feature",
-					"Delete Feature",
-				},
-				false,
-			),
+			[]string{
+				"feature.view",
+				"feature.create",
+				"feature.update",
+				"feature.delete",
+			},
+			false,
 			featureController.GetFeature,
 		)
 
 		// Create
-		moduleRoutes.POST(
+		middlewares.HandleWithPermission(
+			moduleRoutes,
+			http.MethodPost,
 			"",
-			middlewares.Authorization(
-				[]string{"Create Feature"},
-				false,
-			),
+			[]string{"feature.create"},
+			false,
 			featureController.CreateFeature,
 		)
 
 		// Update
-		moduleRoutes.PUT(
+		middlewares.HandleWithPermission(
+			moduleRoutes,
+			http.MethodPut,
 			"/:id",
-			middlewares.Authorization(
-				[]string{"Update Feature"},
-				false,
-			),
+			[]string{"feature.update"},
+			false,
 			featureController.UpdateFeature,
 		)
 
 		// Delete
-		moduleRoutes.DELETE(
+		middlewares.HandleWithPermission(
+			moduleRoutes,
+			http.MethodDelete,
 			"/:id",
-			middlewares.Authorization(
-				[]string{"Delete Feature"},
-				false,
-			),
+			[]string{"feature.delete"},
+			false,
 			featureController.DeleteFeature,
 		)
 	}
@@ -0,0 +1,34 @@
+package accesses
+
+import (
+	"jxb-eprocurement/controllers"
+	"jxb-eprocurement/middlewares"
+	"jxb-eprocurement/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func InitLoginHistoryRoutes(r *gin.RouterGroup, db *gorm.DB) {
+	// Setup controller and route
+	loginHistoryController := controllers.LoginHistoryControllerConstructor(service.LoginHistoryServiceConstructor(db))
+	loginHistoryRoutes := r.Group("/login-history")
+
+	// Additional middleware to implement to the group routes
+	loginHistoryRoutes.Use(middlewares.Authentication())
+	loginHistoryRoutes.Use(middlewares.RateLimitReadWrite(middlewares.ReadRateLimitPolicy(), middlewares.WriteRateLimitPolicy()))
+
+	// Collection of routes
+	{
+		// Search
+		middlewares.HandleWithPermission(
+			loginHistoryRoutes,
+			http.MethodGet,
+			"",
+			[]string{"user.view_login_history"},
+			true,
+			loginHistoryController.GetAllLoginHistory,
+		)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"jxb-eprocurement/controllers"
 	"jxb-eprocurement/middlewares"
 	"jxb-eprocurement/service"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,66 +17,67 @@ func InitModuleRoutes(r *gin.RouterGroup, db *gorm.DB) {
 
 	// Additional middleware to implement to the group routes
 	moduleRoutes.Use(middlewares.Authentication())
+	moduleRoutes.Use(middlewares.RateLimitReadWrite(middlewares.ReadRateLimitPolicy(), middlewares.WriteRateLimitPolicy()))
 
 	// Collection of routes
 	{
 		// Get All
-		moduleRoutes.GET(
+		middlewares.HandleWithPermission(
+			moduleRoutes,
+			http.MethodGet,
 			"",
-			middlewares.Authorization(
-				[]string{
-					"View Module",
-					"Create Module",
-					"Update Module",
-					"Delete Module",
-				},
-				false,
-			),
+			[]string{
+				"module.view",
+				"module.create",
+				"module.update",
+				"module.delete",
+			},
+			false,
 			moduleController.GetAllModules,
 		)
 
 		// Get Detail
-		moduleRoutes.GET(
+		middlewares.HandleWithPermission(
+			moduleRoutes,
+			http.MethodGet,
 			"/:id",
-			middlewares.Authorization(
-				[]string{
-					"View Module",
-					"Create Module",
-					"Update Module",
-					"Delete Module",
-				},
-				false,
-			),
+			[]string{
+				"module.view",
+				"module.create",
+				"module.update",
+				"module.delete",
+			},
+			false,
 			moduleController.GetModule,
 		)
 
 		// Create
-		moduleRoutes.POST(
+		middlewares.HandleWithPermission(
+			moduleRoutes,
+			http.MethodPost,
 			"",
-			middlewares.Authorization(
-				[]string{"Create Module"},
-				false,
-			),
+			[]string{"module.create"},
+			false,
 			moduleController.CreateModule,
 		)
 
 		// Update
-		moduleRoutes.PUT(
+		middlewares.HandleWithPermission(
+			moduleRoutes,
+			http.MethodPut,
 			"/:id",
-			middlewares.Authorization(
-				[]string{"Update Module"},
-				false,
-			),
+			[]string{"module.update"},
+			false,
 			moduleController.UpdateModule,
 		)
 
 		// Delete
-		moduleRoutes.DELETE(
+		middlewares.HandleWithPermission(
+			moduleRoutes,
+			http.MethodDelete,
 			"/:id",
-			middlewares.Authorization(
-				[]string{"Delete Module"},
-				false,
-			),
+			[]string{"module.delete"},
+			false,
 			moduleController.DeleteModule,
 		)
 	}
@@ -0,0 +1,94 @@
+package accesses
+
+import (
+	"jxb-eprocurement/controllers"
+	"jxb-eprocurement/middlewares"
+	"jxb-eprocurement/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func InitPolicyRoutes(r *gin.RouterGroup, db *gorm.DB) {
+	// Setup controller and route
+	policyController := controllers.PolicyControllerConstructor(service.PolicyServiceConstructor(db))
+	policyRoutes := r.Group("/policies")
+
+	// Additional middleware to implement to the group routes
+	policyRoutes.Use(middlewares.Authentication())
+	policyRoutes.Use(middlewares.RateLimitReadWrite(middlewares.ReadRateLimitPolicy(), middlewares.WriteRateLimitPolicy()))
+
+	// Collection of routes
+	{
+		// Get All
+		middlewares.HandleWithPermission(
+			policyRoutes,
+			http.MethodGet,
+			"",
+			[]string{
+				"policy.view",
+				"policy.create",
+				"policy.update",
+				"policy.delete",
+			},
+			true,
+			policyController.GetAllPolicies,
+		)
+
+		// Get Detail
+		middlewares.HandleWithPermission(
+			policyRoutes,
+			http.MethodGet,
+			"/:id",
+			[]string{
+				"policy.view",
+				"policy.create",
+				"policy.update",
+				"policy.delete",
+			},
+			true,
+			policyController.GetPolicy,
+		)
+
+		// Create
+		middlewares.HandleWithPermission(
+			policyRoutes,
+			http.MethodPost,
+			"",
+			[]string{"policy.create"},
+			true,
+			policyController.CreatePolicy,
+		)
+
+		// Dry run decision of a user with explanation
+		middlewares.HandleWithPermission(
+			policyRoutes,
+			http.MethodPost,
+			"/dry-run",
+			[]string{"policy.view"},
+			true,
+			policyController.DryRunPolicy,
+		)
+
+		// Update
+		middlewares.HandleWithPermission(
+			policyRoutes,
+			http.MethodPut,
+			"/:id",
+			[]string{"policy.update"},
+			true,
+			policyController.UpdatePolicy,
+		)
+
+		// Delete
+		middlewares.HandleWithPermission(
+			policyRoutes,
+			http.MethodDelete,
+			"/:id",
+			[]string{"policy.delete"},
+			true,
+			policyController.DeletePolicy,
+		)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"jxb-eprocurement/controllers"
 	"jxb-eprocurement/middlewares"
 	"jxb-eprocurement/service"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,65 +17,67 @@ func InitRoleRoutes(r *gin.RouterGroup, db *gorm.DB) {
 
 	// Additional middleware to implement to the group routes
 	roleRoutes.Use(middlewares.Authentication()) // Uncomment this when the user module and feature module is finish
+	roleRoutes.Use(middlewares.RateLimitReadWrite(middlewares.ReadRateLimitPolicy(), middlewares.WriteRateLimitPolicy()))
 
 	// Collection of routes
 	{
 		// Get All
-		roleRoutes.GET(
+		middlewares.HandleWithPermission(
+			roleRoutes,
+			http.MethodGet,
 			"",
-			middlewares.Authorization(
-				[]string{
-					"View Role",
-					"Create Role",
-					"Update Role",
-					"Delete Role",
-				},
-				false,
-			),
+			[]string{
+				"role.view",
+				"role.create",
+				"role.update",
+				"role.delete",
+			},
+			false,
 			roleController.GetAllRoles,
 		)
 
 		// Get Detail
-		roleRoutes.GET(
+		middlewares.HandleWithPermission(
+			roleRoutes,
+			http.MethodGet,
 			"/:id",
-			middlewares.Authorization(
-				[]string{
-					"View Role",
-					"Create Role",
-					"Update Role",
-					"Delete Role",
-				},
-				false,
-			),
+			[]string{
+				"role.view",
+				"role.create",
+				"role.update",
+				"role.delete",
+			},
+			false,
 			roleController.GetRole,
 		)
 
 		// Create
-		roleRoutes.POST(
+		middlewares.HandleWithPermission(
+			roleRoutes,
+			http.MethodPost,
 			"",
-			middlewares.Authorization(
-				[]string{"Create Role"},
-				false,
-			),
+			[]string{"role.create"},
+			false,
 			roleController.CreateRole,
 		)
 
 		// Update
-		roleRoutes.PUT(
+		middlewares.HandleWithPermission(
+			roleRoutes,
+			http.MethodPut,
 			"/:id",
-			middlewares.Authorization(
-				[]string{"Update Role"},
-				false,
-			),
+			[]string{"role.update"},
+			false,
 			roleController.UpdateRole,
 		)
 
 		// Delete
-		roleRoutes.DELETE(
-			"/:id", middlewares.Authorization(
-				[]string{"Delete Role"},
-				false,
-			),
+		middlewares.HandleWithPermission(
+			roleRoutes,
+			http.MethodDelete,
+			"/:id",
+			[]string{"role.delete"},
+			false,
 			roleController.DeleteRole,
 		)
 	}
@@ -0,0 +1,89 @@
+package accesses
+
+import (
+	"jxb-eprocurement/controllers"
+	"jxb-eprocurement/middlewares"
+	"jxb-eprocurement/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func InitServiceAccountRoutes(r *gin.RouterGroup, db *gorm.DB) {
+	// Setup controller and route
+	serviceAccountController := controllers.ServiceAccountControllerConstructor(service.ServiceAccountServiceConstructor(db))
+	serviceAccountRoutes := r.Group("/service-accounts")
+
+	// Additional middleware to implement to the group routes
+	serviceAccountRoutes.Use(middlewares.Authentication())
+	serviceAccountRoutes.Use(middlewares.RateLimitReadWrite(middlewares.ReadRateLimitPolicy(), middlewares.WriteRateLimitPolicy()))
+
+	// Collection of routes
+	{
+		// Get All
+		middlewares.HandleWithPermission(
+			serviceAccountRoutes,
+			http.MethodGet,
+			"",
+			[]string{
+				"service_account.view",
+				"service_account.create",
+				"service_account.delete",
+				"service_account.manage_api_key",
+			},
+			true,
+			serviceAccountController.GetAllServiceAccounts,
+		)
+
+		// Create
+		middlewares.HandleWithPermission(
+			serviceAccountRoutes,
+			http.MethodPost,
+			"",
+			[]string{"service_account.create"},
+			true,
+			serviceAccountController.CreateServiceAccount,
+		)
+
+		// Delete
+		middlewares.HandleWithPermission(
+			serviceAccountRoutes,
+			http.MethodDelete,
+			"/:id",
+			[]string{"service_account.delete"},
+			true,
+			serviceAccountController.DeleteServiceAccount,
+		)
+
+		// Get API Keys
+		middlewares.HandleWithPermission(
+			serviceAccountRoutes,
+			http.MethodGet,
+			"/:id/api-keys",
+			[]string{"service_account.manage_api_key"},
+			true,
+			serviceAccountController.GetAPIKeys,
+		)
+
+		// Create API Key
+		middlewares.HandleWithPermission(
+			serviceAccountRoutes,
+			http.MethodPost,
+			"/:id/api-keys",
+			[]string{"service_account.manage_api_key"},
+			true,
+			serviceAccountController.CreateAPIKey,
+		)
+
+		// Revoke API Key
+		middlewares.HandleWithPermission(
+			serviceAccountRoutes,
+			http.MethodDelete,
+			"/:id/api-keys/:key_id",
+			[]string{"service_account.manage_api_key"},
+			true,
+			serviceAccountController.RevokeAPIKey,
+		)
+	}
+}
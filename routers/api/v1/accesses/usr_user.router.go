@@ -4,6 +4,7 @@ import (
 	"jxb-eprocurement/controllers"
 	"jxb-eprocurement/middlewares"
 	"jxb-eprocurement/service"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -12,77 +13,166 @@ import (
 func InitUserRoutes(r *gin.RouterGroup, db *gorm.DB) {
 	// Setup controller and route
 	userController := controllers.UserControllerConstructor(service.UserServiceConstructor(db))
+	sessionController := controllers.SessionControllerConstructor(service.SessionServiceConstructor(db))
+	loginHistoryController := controllers.LoginHistoryControllerConstructor(service.LoginHistoryServiceConstructor(db))
 	userRoutes := r.Group("/users")
 
 	// Additional middleware to implement to the group routes
 	userRoutes.Use(middlewares.Authentication())
+	userRoutes.Use(middlewares.RateLimitReadWrite(middlewares.ReadRateLimitPolicy(), middlewares.WriteRateLimitPolicy()))
 
 	// Collection of routes
 	{
 		// Get All
-		userRoutes.GET(
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodGet,
 			"",
-			middlewares.Authorization(
-				[]string{
-					"View User",
-					"Create User",
-					"Update User",
-					"Delete User",
-					"Reset User Password",
-				},
-				false,
-			),
+			[]string{
+				"user.view",
+				"user.create",
+				"user.update",
+				"user.delete",
+				"user.reset_password",
+			},
+			false,
 			userController.GetAllUsers,
 		)
 
 		// Get Detail
-		userRoutes.GET(
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodGet,
 			"/:id",
-			middlewares.Authorization(
-				[]string{
-					"View User",
-					"Create User",
-					"Update User",
-					"Delete User",
-				},
-				false,
-			),
+			[]string{
+				"user.view",
+				"user.create",
+				"user.update",
+				"user.delete",
+			},
+			false,
 			userController.GetUser,
 		)
 
 		// Create
-		userRoutes.POST(
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodPost,
 			"",
-			middlewares.Authorization([]string{"Create User"}, false),
+			[]string{"user.create"},
+			false,
 			userController.CreateUser,
 		)
 
 		// Edit
-		userRoutes.PUT(
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodPut,
 			"/:id",
-			middlewares.Authorization([]string{"Update User"}, false),
+			[]string{"user.update"},
+			false,
 			userController.UpdateUser,
 		)
 
 		// Delete
-		userRoutes.DELETE(
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodDelete,
 			"/:id",
-			middlewares.Authorization([]string{"Delete User"}, false),
+			[]string{"user.delete"},
+			false,
 			userController.DeleteUser,
 		)
 
 		// Reset Password
-		userRoutes.PATCH(
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodPatch,
 			"/reset-pass/:id",
-			middlewares.Authorization([]string{"Reset User Password"}, true),
+			[]string{"user.reset_password"},
+			true,
 			userController.ResetPassUser,
 		)
 
 		// Change Password
-		userRoutes.PATCH(
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodPatch,
 			"/change-pass/:id",
-			middlewares.Authorization([]string{"Reset User Password"}, false),
+			[]string{"user.change_password"},
+			false,
+			middlewares.DenyImpersonation(),
 			userController.ChangePassUser,
 		)
+
+		// Unlock Login
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodPatch,
+			"/unlock/:id",
+			[]string{"user.unlock"},
+			true,
+			userController.UnlockUser,
+		)
+
+		// Approve Registered Vendor
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodPatch,
+			"/approve/:id",
+			[]string{"vendor.validate"},
+			false,
+			userController.ApproveUser,
+		)
+
+		// Get Login History
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodGet,
+			"/:id/login-history",
+			[]string{"user.view_login_history"},
+			true,
+			loginHistoryController.GetUserLoginHistory,
+		)
+
+		// Impersonate
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodPost,
+			"/:id/impersonate",
+			[]string{"user.impersonate"},
+			true,
+			userController.ImpersonateUser,
+		)
+
+		// Get Sessions
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodGet,
+			"/:id/sessions",
+			[]string{"user.revoke_session"},
+			true,
+			sessionController.GetUserSessions,
+		)
+
+		// Revoke Session
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodDelete,
+			"/:id/sessions/:session_id",
+			[]string{"user.revoke_session"},
+			true,
+			sessionController.RevokeUserSession,
+		)
+
+		// Revoke All Sessions
+		middlewares.HandleWithPermission(
+			userRoutes,
+			http.MethodDelete,
+			"/:id/sessions",
+			[]string{"user.revoke_session"},
+			true,
+			userController.RevokeSessionsUser,
+		)
 	}
 }
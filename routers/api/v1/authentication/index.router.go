@@ -2,6 +2,8 @@ package authentication
 
 import (
 	"jxb-eprocurement/controllers"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/middlewares"
 	"jxb-eprocurement/service"
 
 	"github.com/gin-gonic/gin"
@@ -9,10 +11,56 @@ import (
 )
 
 func InitAuthRoutes(r *gin.RouterGroup, db *gorm.DB) {
-	authController := controllers.AuthControllerConstructor(service.AuthServiceConstructor(db))
+	mailer := helpers.NewSMTPMailSender()
+	notifier := helpers.NewNotifier(mailer)
+	authController := controllers.AuthControllerConstructor(service.AuthServiceConstructor(db, notifier))
+	twoFactorController := controllers.TwoFactorControllerConstructor(service.TwoFactorServiceConstructor(db, notifier))
+	passwordResetController := controllers.PasswordResetControllerConstructor(service.PasswordResetServiceConstructor(db, mailer))
+	registrationController := controllers.RegistrationControllerConstructor(service.RegistrationServiceConstructor(db, mailer))
+	oidcController := controllers.OIDCControllerConstructor(service.OIDCServiceConstructor(db, notifier))
+	sessionController := controllers.SessionControllerConstructor(service.SessionServiceConstructor(db))
+	loginHistoryController := controllers.LoginHistoryControllerConstructor(service.LoginHistoryServiceConstructor(db))
 	authRoutes := r.Group("/auth")
 
+	// Rate limit of the routes, login is limited per ip strictly and authenticated routes per user
+	loginLimit := middlewares.RateLimit(middlewares.LoginRateLimitPolicy())
+	authLimit := middlewares.RateLimit(middlewares.AuthRateLimitPolicy())
+	userLimit := middlewares.RateLimitReadWrite(middlewares.ReadRateLimitPolicy(), middlewares.WriteRateLimitPolicy())
+
+	{
+		authRoutes.POST("/login", loginLimit, authController.LoginUser)
+		authRoutes.POST("/refresh", authLimit, authController.RefreshToken)
+		authRoutes.POST("/logout", middlewares.Authentication(), userLimit, authController.LogoutUser)
+		authRoutes.GET("/me", middlewares.Authentication(), userLimit, authController.Me)
+		authRoutes.POST("/can", middlewares.Authentication(), userLimit, authController.Can)
+		authRoutes.GET("/login-history", middlewares.Authentication(), userLimit, loginHistoryController.GetMyLoginHistory)
+		authRoutes.POST("/register", authLimit, registrationController.Register)
+		authRoutes.POST("/verify-email", authLimit, registrationController.VerifyEmail)
+		authRoutes.POST("/resend-verification", authLimit, registrationController.ResendVerification)
+		authRoutes.POST("/forgot-password", authLimit, passwordResetController.ForgotPassword)
+		authRoutes.POST("/reset-password", authLimit, passwordResetController.ResetPassword)
+		authRoutes.POST("/change-expired-password", authLimit, passwordResetController.ChangeExpiredPassword)
+	}
+
+	twoFactorRoutes := authRoutes.Group("/2fa")
+	{
+		twoFactorRoutes.POST("/verify", loginLimit, twoFactorController.VerifyTwoFactor)
+		twoFactorRoutes.POST("/enroll", middlewares.TwoFactorEnrollmentAuthentication(), middlewares.DenyImpersonation(), userLimit, twoFactorController.EnrollTwoFactor)
+		twoFactorRoutes.POST("/activate", middlewares.TwoFactorEnrollmentAuthentication(), middlewares.DenyImpersonation(), userLimit, twoFactorController.ActivateTwoFactor)
+		twoFactorRoutes.POST("/disable", middlewares.Authentication(), middlewares.DenyImpersonation(), userLimit, twoFactorController.DisableTwoFactor)
+	}
+
+	sessionRoutes := authRoutes.Group("/sessions", middlewares.Authentication(), userLimit)
+	{
+		sessionRoutes.GET("", sessionController.GetMySessions)
+		sessionRoutes.DELETE("", middlewares.DenyImpersonation(), sessionController.RevokeAllMySessions)
+		sessionRoutes.DELETE("/:id", middlewares.DenyImpersonation(), sessionController.RevokeMySession)
+	}
+
+	oidcRoutes := authRoutes.Group("/oidc")
 	{
-		authRoutes.POST("/login", authController.LoginUser)
+		oidcRoutes.GET("/login", authLimit, oidcController.LoginOIDC)
+		oidcRoutes.GET("/callback", loginLimit, oidcController.CallbackOIDC)
+		oidcRoutes.POST("/callback", loginLimit, oidcController.CallbackOIDC)
 	}
 }
@@ -1,6 +1,7 @@
 package routers
 
 import (
+	"jxb-eprocurement/controllers"
 	"jxb-eprocurement/handlers"
 	"jxb-eprocurement/middlewares"
 	apis "jxb-eprocurement/routers/api"
@@ -17,6 +18,9 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	router.Use(middlewares.RequestIDMiddleware())
 	router.Use(handlers.APILogger())
 
+	// Public keys for verifying token issued by this service
+	router.GET("/.well-known/jwks.json", controllers.JWKSControllerConstructor().GetJWKS)
+
 	// Initialize route groups for versioning
 	apis.InitRoutes(router, db)
 
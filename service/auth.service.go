@@ -6,27 +6,33 @@ import (
 	"jxb-eprocurement/handlers/dtos"
 	"jxb-eprocurement/helpers"
 	"jxb-eprocurement/models"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 // AuthService defines the methods for the auth service.
 type AuthService interface {
 	Login(c *gin.Context) handlers.ServiceResponseWithLogging
+	Refresh(c *gin.Context) handlers.ServiceResponseWithLogging
+	Logout(c *gin.Context) handlers.ServiceResponseWithLogging
+	Me(c *gin.Context) handlers.ServiceResponseWithLogging
+	Can(c *gin.Context) handlers.ServiceResponseWithLogging
 }
 
 // AuthServiceImpl is the implementation of the AuthService interface.
 type AuthServiceImpl struct {
-	db *gorm.DB
+	db       *gorm.DB
+	notifier helpers.Notifier
 }
 
 // NewAuthService creates a new instance of AuthServiceImpl.
-func AuthServiceConstructor(db *gorm.DB) AuthService {
-	return &AuthServiceImpl{db: db}
+func AuthServiceConstructor(db *gorm.DB, notifier helpers.Notifier) AuthService {
+	return &AuthServiceImpl{db: db, notifier: notifier}
 }
 
 // Validate user input that validator cannot check by validator v10 package
@@ -39,12 +45,17 @@ func (a *AuthServiceImpl) inputValidator(input dtos.InputLoginDTO, c *gin.Contex
 	log := helpers.CreateLog(c, a)
 
 	// Check user with email  or username exist
-	if result := a.db.Preload("Role").Preload("Role.Features").Preload("Role.Features.Module").Limit(1).Where("email = ?", input.UsernameOrEmail).Or("username = ?", input.UsernameOrEmail).Find(&user); result.Error != nil || result.RowsAffected == 0 {
+	if result := helpers.PreloadUserRoles(a.db).Limit(1).Where("email = ?", input.UsernameOrEmail).Or("username = ?", input.UsernameOrEmail).Find(&user); result.Error != nil || result.RowsAffected == 0 {
 		isError = true
 	}
 
 	// Check password form hashes form
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+	if !helpers.VerifyPassword(user.Password, input.Password) {
+		isError = true
+	}
+
+	// Service account only authenticate using api key
+	if user.IsServiceAccount {
 		isError = true
 	}
 
@@ -57,6 +68,99 @@ func (a *AuthServiceImpl) inputValidator(input dtos.InputLoginDTO, c *gin.Contex
 	return user, isError
 }
 
+// Response data of successful login
+func loginData(user models.USR_User, tokens dtos.TokenDTO) map[string]interface{} {
+	return map[string]interface{}{
+		"loginAt":            time.Now(),
+		"user":               user.Name,
+		"role":               user.Role.Name,
+		"roles":              helpers.UserRoleNames(user),
+		"token":              tokens.Token,
+		"expires_at":         tokens.ExpiresAt,
+		"refresh_token":      tokens.RefreshToken,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
+	}
+}
+
+// Refresh token that already rotated is used again
+var errRefreshTokenReused = fmt.Errorf("refresh token reuse detected")
+
+// Message for user whose status doesn't allow login, empty when user can login
+func inactiveUserMessage(user models.USR_User) string {
+	switch user.Status {
+	case models.UserStatusActive:
+		return ""
+	case models.UserStatusPendingVerification:
+		return "Email not verified, please check your email"
+	case models.UserStatusPendingApproval:
+		return "Account is waiting for approval"
+	}
+
+	return "Account is not active"
+}
+
+// Reason the user can't get new token by refresh token, the same condition as login. Empty when
+// refresh is allowed.
+func refreshDeniedReason(user models.USR_User) string {
+	if user.IsServiceAccount {
+		return "Service account can't login"
+	}
+	if message := inactiveUserMessage(user); message != "" {
+		return message
+	}
+	if helpers.GetPasswordPolicy().IsExpired(user) {
+		return "Password expired, must change"
+	}
+	return ""
+}
+
+// Finish login of authenticated user, user with two factor get challenge instead of token.
+// Login is recorded into login history once the token is issued.
+func finishLogin(db *gorm.DB, notifier helpers.Notifier, c *gin.Context, user models.USR_User, identifier string, method string, log handlers.Log) handlers.ServiceResponseWithLogging {
+	// User with two factor (or role that require it) must finish second step before get token
+	if user.TOTPEnabled || helpers.IsTwoFactorRequired(user) {
+		challenge, error := newTwoFactorChallenge(user)
+		if error != nil {
+			return handlers.ServiceResponseWithLogging{
+				Status:  http.StatusBadRequest,
+				Message: "Failed to generate token",
+				Data:    nil,
+				Err:     error.Error(),
+				Log:     log,
+			}
+		}
+
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusOK,
+			Message: "Two Factor Authentication Required",
+			Data:    challenge,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	tokens, error := createSession(db, c, user)
+	if error != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Failed to generate token",
+			Data:    nil,
+			Err:     error.Error(),
+			Log:     log,
+		}
+	}
+
+	recordLoginAttempt(db, c, notifier, user, identifier, method, models.LoginReasonSuccess, log)
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "User Login Successfully",
+		Data:    loginData(user, tokens),
+		Err:     nil,
+		Log:     log,
+	}
+}
+
 // AddAuthData adds a new user to the database.
 func (a *AuthServiceImpl) Login(c *gin.Context) handlers.ServiceResponseWithLogging {
 	log := helpers.CreateLog(c, a)
@@ -84,9 +188,27 @@ func (a *AuthServiceImpl) Login(c *gin.Context) handlers.ServiceResponseWithLogg
 		}
 	}
 
+	// Reject attempt while the account or client ip is delayed or locked
+	accountKey := accountThrottleKey(a.db, input.UsernameOrEmail)
+	throttleKeys := []string{accountKey, ipThrottleKey(c.ClientIP())}
+	if wait, allowed := checkLoginThrottle(a.db, throttleKeys...); !allowed {
+		recordLoginAttempt(a.db, c, a.notifier, models.USR_User{}, input.UsernameOrEmail, models.LoginMethodPassword, models.LoginReasonThrottled, log)
+		retryAfter := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusTooManyRequests,
+			Message: fmt.Sprintf("Too many failed login attempts, try again in %d seconds", retryAfter),
+			Data:    nil,
+			Err:     "Login throttled",
+			Log:     log,
+		}
+	}
+
 	// Check and validate input that cannot be validate by golang validator
 	user, err := a.inputValidator(input, c)
 	if err {
+		registerFailedLogin(a.db, c, accountKey, input.UsernameOrEmail, log)
+		recordLoginAttempt(a.db, c, a.notifier, user, input.UsernameOrEmail, models.LoginMethodPassword, models.LoginReasonInvalidCredentials, log)
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusBadRequest,
 			Message: "Invalid email or password",
@@ -96,28 +218,484 @@ func (a *AuthServiceImpl) Login(c *gin.Context) handlers.ServiceResponseWithLogg
 		}
 	}
 
-	token, error := helpers.GenerateJWT(user)
-	if error != nil {
+	// Successful login reset the account counter, ip counter is left to expire by itself
+	clearLoginThrottle(a.db, userThrottleKey(user.ID))
+
+	// Upgrade hash made by older algorithm or cost, failure only logged since login itself is valid
+	if err := rehashPasswordIfNeeded(a.db, &user, input.Password); err != nil {
+		handlers.WriteLog(c, http.StatusInternalServerError, "Failed to rehash password", err.Error(), log)
+	}
+
+	// Only active user can login, status is checked after password so it doesn't leak
+	if message := inactiveUserMessage(user); message != "" {
+		recordLoginAttempt(a.db, c, a.notifier, user, input.UsernameOrEmail, models.LoginMethodPassword, models.LoginReasonInactive, log)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusForbidden,
+			Message: message,
+			Data:    nil,
+			Err:     fmt.Sprintf("User status is %s", user.Status),
+			Log:     log,
+		}
+	}
+
+	// Expired password must be changed before user can get token
+	if helpers.GetPasswordPolicy().IsExpired(user) {
+		recordLoginAttempt(a.db, c, a.notifier, user, input.UsernameOrEmail, models.LoginMethodPassword, models.LoginReasonPasswordExpired, log)
+		token, claims, error := helpers.GenerateChallengeToken(user.ID, helpers.ChallengePurposePasswordChange)
+		if error != nil {
+			return handlers.ServiceResponseWithLogging{
+				Status:  http.StatusBadRequest,
+				Message: "Failed to generate token",
+				Data:    nil,
+				Err:     error.Error(),
+				Log:     log,
+			}
+		}
+
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusForbidden,
+			Message: "Password expired, must change",
+			Data: dtos.PasswordChangeChallengeDTO{
+				PasswordChangeRequired: true,
+				ChallengeToken:         token,
+				ExpiresAt:              time.Unix(claims.ExpiresAt, 0),
+			},
+			Err: nil,
+			Log: log,
+		}
+	}
+
+	return finishLogin(a.db, a.notifier, c, user, input.UsernameOrEmail, models.LoginMethodPassword, log)
+}
+
+// Refresh rotate the given refresh token and issue new access and refresh token.
+// Reusing refresh token that already rotated is treated as token theft, so the whole
+// token family (session) is revoked.
+func (a *AuthServiceImpl) Refresh(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, a)
+	var input dtos.InputRefreshTokenDTO
+
+	if err := c.ShouldBind(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	// Check refresh token existence
+	var refreshToken models.USR_RefreshToken
+	result := a.db.Preload("Session").Limit(1).Where("token_hash = ?", helpers.HashToken(input.RefreshToken)).Find(&refreshToken)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid refresh token",
+			Data:    nil,
+			Err:     "Refresh token not found",
+			Log:     log,
+		}
+	}
+
+	session := refreshToken.Session
+	if session.RevokedAt != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid refresh token",
+			Data:    nil,
+			Err:     "Session already revoked",
+			Log:     log,
+		}
+	}
+
+	if refreshToken.ExpiresAt.Before(time.Now()) {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Refresh token has expired",
+			Data:    nil,
+			Err:     "Refresh token has expired",
+			Log:     log,
+		}
+	}
+
+	// Fetch the latest user data so the new token carry the current role
+	var user models.USR_User
+	result = helpers.PreloadUserRoles(a.db).Limit(1).Where("id = ?", session.UserID).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid refresh token",
+			Data:    nil,
+			Err:     "User not found",
+			Log:     log,
+		}
+	}
+
+	// User must still be allowed to login, otherwise the session is ended
+	if reason := refreshDeniedReason(user); reason != "" {
+		if err := revokeSession(a.db, session.ID, reason); err != nil {
+			handlers.WriteLog(c, http.StatusInternalServerError, "Failed to revoke session", err.Error(), log)
+		}
+
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid refresh token",
+			Data:    nil,
+			Err:     fmt.Sprintf("%s, session %d revoked", reason, session.ID),
+			Log:     log,
+		}
+	}
+
+	var tokens dtos.TokenDTO
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		// Mark the token as used, the where clause make sure only one request could rotate the
+		// token. It is rolled back when the new token can't be issued so the client could retry.
+		result := tx.Model(&models.USR_RefreshToken{}).Where("id = ? AND used_at IS NULL", refreshToken.ID).Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRefreshTokenReused
+		}
+
+		var err error
+		tokens, err = issueSessionTokens(tx, user, &session)
+		return err
+	})
+
+	// Token already rotated before, revoke the whole token family
+	if err == errRefreshTokenReused {
+		if err := revokeSession(a.db, session.ID, "Refresh token reuse detected"); err != nil {
+			handlers.WriteLog(c, http.StatusInternalServerError, "Failed to revoke session", err.Error(), log)
+		}
+
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid refresh token",
+			Data:    nil,
+			Err:     fmt.Sprintf("Refresh token reuse detected, session %d revoked", session.ID),
+			Log:     log,
+		}
+	}
+	if err != nil {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusBadRequest,
 			Message: "Failed to generate token",
 			Data:    nil,
-			Err:     error.Error(),
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Token Refreshed Successfully",
+		Data:    tokens,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// Logout revoke the access token used on the request and the session it belong to,
+// so refresh token from the session can't be used anymore.
+func (a *AuthServiceImpl) Logout(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, a)
+
+	var claims dtos.Claims
+	helpers.GetClaimsPayload(c, &claims)
+	if claims.Id == "" {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid token",
+			Data:    nil,
+			Err:     "Token claims not found",
 			Log:     log,
 		}
 	}
 
-	data := map[string]interface{}{
-		"loginAt": time.Now(),
-		"user":    user.Name,
-		"role":    user.Role.Name,
-		"token":   fmt.Sprintf("Bearer %s", token),
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := helpers.RevokeToken(tx, claims.Id, claims.UserID, time.Unix(claims.ExpiresAt, 0), "Logout"); err != nil {
+			return err
+		}
+
+		return revokeSession(tx, claims.SessionID, "Logout")
+	})
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Revoking Token",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
 	}
 
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusOK,
-		Message: "User Login Successfully",
-		Data:    data,
+		Message: "User Logout Successfully",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// Build module tree from flat module list, only module in allowed (or having allowed
+// descendant) is kept
+func buildModuleTree(modules []models.USR_Module, parentID *uint, allowed map[uint]struct{}) []dtos.USRModuleDTO {
+	tree := []dtos.USRModuleDTO{}
+	for _, module := range modules {
+		if (parentID == nil) != (module.ParentID == nil) || (parentID != nil && *parentID != *module.ParentID) {
+			continue
+		}
+
+		id := module.ID
+		children := buildModuleTree(modules, &id, allowed)
+		if _, exists := allowed[module.ID]; !exists && len(children) == 0 {
+			continue
+		}
+
+		tree = append(tree, dtos.USRModuleDTO{
+			ID:       module.ID,
+			Name:     module.Name,
+			ParentID: module.ParentID,
+			Children: children,
+		})
+	}
+
+	return tree
+}
+
+// Me return profile of the current user with live features and accessible module tree.
+func (a *AuthServiceImpl) Me(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, a)
+
+	var userPayload models.USR_User
+	helpers.GetUserPayload(c, &userPayload)
+
+	permission, err := helpers.ResolveUserPermission(a.db, userPayload.ID)
+	if err == gorm.ErrRecordNotFound {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "User no longer exist",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to resolve user permission",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// API key can only use the features granted to the key
+	features := helpers.RequestFeatures(c, permission)
+
+	var user models.USR_User
+	if err := a.db.Preload("Role").Preload("Roles").First(&user, permission.UserID).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Getting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Module that directly own at least one feature of the user
+	var moduleIDs []uint
+	if len(features) != 0 {
+		if err := a.db.Model(&models.USR_Feature{}).Where("code IN ?", features).Distinct().Pluck("module_id", &moduleIDs).Error; err != nil {
+			return handlers.ServiceResponseWithLogging{
+				Status:  http.StatusInternalServerError,
+				Message: "Error Getting Data",
+				Data:    nil,
+				Err:     err.Error(),
+				Log:     log,
+			}
+		}
+	}
+	allowedModules := make(map[uint]struct{}, len(moduleIDs))
+	for _, moduleID := range moduleIDs {
+		allowedModules[moduleID] = struct{}{}
+	}
+
+	var modules []models.USR_Module
+	if err := a.db.Order("id").Find(&modules).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Getting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	roles := []dtos.USRRoleMinimalDTO{}
+	for _, role := range user.EffectiveRoles() {
+		roles = append(roles, dtos.ToUSRRoleMinimalDTO(*role))
+	}
+
+	// Frontend show the real user when the token is impersonation token
+	var claims dtos.Claims
+	helpers.GetClaimsPayload(c, &claims)
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Success Getting Current User Data",
+		Data: dtos.ProfileDTO{
+			ID:           user.ID,
+			Username:     user.Username,
+			Name:         user.Name,
+			Email:        user.Email,
+			Status:       user.Status,
+			TOTPEnabled:  user.TOTPEnabled,
+			Impersonator: claims.Act,
+			Role: dtos.USRRoleMinimalDTO{
+				ID:               permission.RoleID,
+				Name:             permission.Role,
+				IsAdministrative: permission.IsAdministrative,
+			},
+			Roles:    roles,
+			Features: features,
+			Modules:  buildModuleTree(modules, nil, allowedModules),
+		},
+		Err: nil,
+		Log: log,
+	}
+}
+
+// Can check access of current user to several features or routes at once, used by frontend to
+// enable the action the user can take. Decision is made by the same logic as Authorization
+// middleware, so the frontend and the api always agree.
+func (a *AuthServiceImpl) Can(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, a)
+	var input dtos.InputPermissionCheckDTO
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	var userPayload models.USR_User
+	helpers.GetUserPayload(c, &userPayload)
+
+	permission, err := helpers.ResolveUserPermission(a.db, userPayload.ID)
+	if err == gorm.ErrRecordNotFound {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "User no longer exist",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to resolve user permission",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// API key can only use the features granted to the key
+	features := helpers.RequestFeatures(c, permission)
+
+	results := make([]dtos.PermissionCheckDTO, len(input.Checks))
+	for i, check := range input.Checks {
+		result := dtos.PermissionCheckDTO{Feature: check.Feature, ResourceID: check.ResourceID, Method: check.Method, Path: check.Path}
+		request := helpers.AccessRequest{
+			AllowedFeatures: []string{check.Feature},
+			IsAdminOnly:     helpers.IsFeatureAdminOnly(check.Feature),
+			ResourceID:      check.ResourceID,
+		}
+
+		// Route is checked exactly like the middleware of the route
+		if check.Path != "" {
+			route, params, found := helpers.FindRoutePermission(check.Method, check.Path)
+			if !found {
+				result.Reason = "Route not found"
+				results[i] = result
+				continue
+			}
+
+			request.AllowedFeatures = route.Features
+			if check.Feature != "" {
+				request.AllowedFeatures = helpers.IntersectFeatures(route.Features, []string{check.Feature})
+			}
+			if len(request.AllowedFeatures) == 0 {
+				result.Reason = fmt.Sprintf("Feature %s is not used by the route", check.Feature)
+				results[i] = result
+				continue
+			}
+
+			request.IsAdminOnly = route.IsAdminOnly
+			request.Method = route.Method
+			request.Path = route.Path
+			if request.ResourceID == "" {
+				request.ResourceID = params["id"]
+			}
+		}
+
+		decision, err := helpers.DecideAccess(a.db, c, permission, features, request)
+		if err != nil {
+			return handlers.ServiceResponseWithLogging{
+				Status:  http.StatusInternalServerError,
+				Message: "Failed to evaluate access policy",
+				Data:    nil,
+				Err:     err.Error(),
+				Log:     log,
+			}
+		}
+
+		result.Allowed = decision.Allowed
+		result.Reason = decision.Reason
+		if decision.Allowed {
+			result.Features = decision.Features
+		}
+		results[i] = result
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Success Checking Permissions",
+		Data:    results,
 		Err:     nil,
 		Log:     log,
 	}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"fmt"
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Save login attempt into login history. Successful login from ip and user agent that the user
+// never used before is flagged and the user is notified, first login of a user is not flagged.
+func recordLoginAttempt(db *gorm.DB, c *gin.Context, notifier helpers.Notifier, user models.USR_User, identifier string, method string, reason string, log handlers.Log) {
+	history := models.USR_LoginHistory{
+		Identifier: identifier,
+		Method:     method,
+		Success:    reason == models.LoginReasonSuccess,
+		Reason:     reason,
+		ClientIP:   c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+	if user.ID != 0 {
+		history.UserID = &user.ID
+	}
+
+	if history.Success && history.UserID != nil {
+		var previous, known int64
+		db.Model(&models.USR_LoginHistory{}).Where("user_id = ? AND success = ?", user.ID, true).Count(&previous)
+		if previous != 0 {
+			db.Model(&models.USR_LoginHistory{}).
+				Where("user_id = ? AND success = ? AND client_ip = ? AND user_agent = ?", user.ID, true, history.ClientIP, history.UserAgent).
+				Count(&known)
+			history.IsNewDevice = known == 0
+		}
+	}
+
+	if err := db.Create(&history).Error; err != nil {
+		handlers.WriteLog(c, http.StatusInternalServerError, "Failed to save login history", err.Error(), log)
+		return
+	}
+
+	if history.IsNewDevice && notifier != nil {
+		requestID := c.GetString("X-Request-ID")
+		message := fmt.Sprintf("We noticed a new login to your account from IP %s (%s) at %s.\r\n\r\nIf this was you, you can ignore this message. If not, change your password and sign out your other sessions right away.",
+			history.ClientIP, history.UserAgent, history.CreatedAt.Format(time.RFC1123))
+		go func() {
+			if err := notifier.Notify(user, "New Login To Your Account", message); err != nil {
+				handlers.LogSystem(handlers.LogSystemParam{
+					Identifier: requestID,
+					StatusCode: http.StatusInternalServerError,
+					Location:   log.Location,
+					Message:    "Failed to send new login notification",
+					StartTime:  log.StartTime,
+					EndTime:    time.Now(),
+					UserInfo:   dtos.LogUserInfo{ID: fmt.Sprint(user.ID), Username: user.Username},
+					Err:        err.Error(),
+				})
+			}
+		}()
+	}
+}
+
+// LoginHistoryService defines the methods for the login history service.
+type LoginHistoryService interface {
+	GetMine(c *gin.Context) handlers.ServiceResponseWithLogging
+	GetByUser(c *gin.Context) handlers.ServiceResponseWithLogging
+	GetAll(c *gin.Context) handlers.ServiceResponseWithLogging
+}
+
+// LoginHistoryServiceImpl is the implementation of the LoginHistoryService interface.
+type LoginHistoryServiceImpl struct {
+	db *gorm.DB
+}
+
+// LoginHistoryServiceConstructor creates a new instance of LoginHistoryServiceImpl.
+func LoginHistoryServiceConstructor(db *gorm.DB) LoginHistoryService {
+	return &LoginHistoryServiceImpl{db: db}
+}
+
+// Apply filter from query string, time range use RFC3339 format (from, to)
+func (l *LoginHistoryServiceImpl) filter(c *gin.Context, query *gorm.DB) (*gorm.DB, map[string]map[string]string) {
+	errors := map[string]map[string]string{"errors": {}}
+
+	if identifier := c.Query("identifier"); identifier != "" {
+		query = query.Where("identifier = ?", identifier)
+	}
+	if clientIP := c.Query("client_ip"); clientIP != "" {
+		query = query.Where("client_ip = ?", clientIP)
+	}
+	if method := c.Query("method"); method != "" {
+		query = query.Where("method = ?", method)
+	}
+	if reason := c.Query("reason"); reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+	if success := c.Query("success"); success != "" {
+		if value, err := strconv.ParseBool(success); err == nil {
+			query = query.Where("success = ?", value)
+		} else {
+			errors["errors"]["success"] = "Success must be true or false"
+		}
+	}
+	if isNewDevice := c.Query("is_new_device"); isNewDevice != "" {
+		if value, err := strconv.ParseBool(isNewDevice); err == nil {
+			query = query.Where("is_new_device = ?", value)
+		} else {
+			errors["errors"]["is_new_device"] = "Is new device must be true or false"
+		}
+	}
+	if from := c.Query("from"); from != "" {
+		if value, err := time.Parse(time.RFC3339, from); err == nil {
+			query = query.Where("created_at >= ?", value)
+		} else {
+			errors["errors"]["from"] = "From must be RFC3339 time"
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if value, err := time.Parse(time.RFC3339, to); err == nil {
+			query = query.Where("created_at <= ?", value)
+		} else {
+			errors["errors"]["to"] = "To must be RFC3339 time"
+		}
+	}
+
+	return query, errors
+}
+
+// Get filtered login history, result is always paginated and latest attempt come first
+func (l *LoginHistoryServiceImpl) search(c *gin.Context, query *gorm.DB, log handlers.Log) handlers.ServiceResponseWithLogging {
+	query, errors := l.filter(c, query)
+	if len(errors["errors"]) != 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	var totalRows int64
+	if err := query.Session(&gorm.Session{}).Count(&totalRows).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Getting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	var histories []models.USR_LoginHistory
+	err := query.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "username").Unscoped()
+	}).Scopes(helpers.Paginate(c)).Order("created_at DESC").Order("id DESC").Find(&histories).Error
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Getting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Success Getting Login History",
+		Data:    helpers.GeneratePaginatedQuery(c, totalRows, dtos.ToUSRLoginHistoryDTOs(histories)),
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// GetMine retrieves login history of the current user.
+func (l *LoginHistoryServiceImpl) GetMine(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, l)
+
+	var userPayload models.USR_User
+	helpers.GetUserPayload(c, &userPayload)
+
+	return l.search(c, l.db.Model(&models.USR_LoginHistory{}).Where("user_id = ?", userPayload.ID), log)
+}
+
+// GetByUser retrieves login history of a user, used by admin.
+func (l *LoginHistoryServiceImpl) GetByUser(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, l)
+
+	// Check Params Validity
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid ID",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Check User Existence, user outside data scope is treated as not found
+	var user models.USR_User
+	result := l.db.Scopes(helpers.ScopeData(helpers.GetDataScope(c), "id")).Limit(1).Where("id = ?", id).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "User not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	return l.search(c, l.db.Model(&models.USR_LoginHistory{}).Where("user_id = ?", user.ID), log)
+}
+
+// GetAll search login history of every user, used by admin.
+func (l *LoginHistoryServiceImpl) GetAll(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, l)
+
+	// Only history of users within data scope
+	query := l.db.Model(&models.USR_LoginHistory{}).Scopes(helpers.ScopeData(helpers.GetDataScope(c), "user_id"))
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := strconv.Atoi(userID)
+		if err != nil {
+			return handlers.ServiceResponseWithLogging{
+				Status:  http.StatusBadRequest,
+				Message: "Invalid User ID",
+				Data:    nil,
+				Err:     err.Error(),
+				Log:     log,
+			}
+		}
+		query = query.Where("user_id = ?", id)
+	}
+
+	return l.search(c, query, log)
+}
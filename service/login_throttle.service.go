@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// loginThrottlePolicy is brute-force protection configuration loaded from env
+type loginThrottlePolicy struct {
+	MaxAccountAttempts int
+	MaxIPAttempts      int
+	LockoutDuration    time.Duration
+	DelayBase          time.Duration
+	DelayMax           time.Duration
+}
+
+func getLoginThrottlePolicy() loginThrottlePolicy {
+	return loginThrottlePolicy{
+		MaxAccountAttempts: helpers.GetENVIntWithDefault("LOGIN_MAX_ATTEMPTS", 5),
+		MaxIPAttempts:      helpers.GetENVIntWithDefault("LOGIN_IP_MAX_ATTEMPTS", 20),
+		LockoutDuration:    time.Duration(helpers.GetENVIntWithDefault("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+		DelayBase:          time.Duration(helpers.GetENVIntWithDefault("LOGIN_DELAY_BASE_SECONDS", 1)) * time.Second,
+		DelayMax:           time.Duration(helpers.GetENVIntWithDefault("LOGIN_DELAY_MAX_SECONDS", 30)) * time.Second,
+	}
+}
+
+// Throttle key of a known user, username and email share the same counter
+func userThrottleKey(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// Throttle key of the login identifier. Identifier of existing user is keyed by the user id, so
+// alternating username and email doesn't give more attempts. Unknown identifier is hashed so the
+// key doesn't reveal whether the account exists.
+func accountThrottleKey(db *gorm.DB, usernameOrEmail string) string {
+	var user models.USR_User
+	result := db.Select("id").Limit(1).Where("email = ?", usernameOrEmail).Or("username = ?", usernameOrEmail).Find(&user)
+	if result.Error == nil && result.RowsAffected != 0 {
+		return userThrottleKey(user.ID)
+	}
+	return "account:" + helpers.HashToken(strings.ToLower(strings.TrimSpace(usernameOrEmail)))
+}
+
+func ipThrottleKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+// Check whether login is allowed for every key, return how long the client must wait when it's not
+func checkLoginThrottle(db *gorm.DB, keys ...string) (time.Duration, bool) {
+	var throttles []models.USR_LoginThrottle
+	db.Where("`key` IN ?", keys).Find(&throttles)
+
+	now := time.Now()
+	var wait time.Duration
+	for _, throttle := range throttles {
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) && throttle.LockedUntil.Sub(now) > wait {
+			wait = throttle.LockedUntil.Sub(now)
+		}
+		if throttle.NextAttemptAt != nil && throttle.NextAttemptAt.After(now) && throttle.NextAttemptAt.Sub(now) > wait {
+			wait = throttle.NextAttemptAt.Sub(now)
+		}
+	}
+
+	return wait, wait == 0
+}
+
+// Increase failure counter of a key. Every failure delay the next attempt progressively
+// and reaching maxAttempts lock the key for the lockout duration. Return true when the key
+// just got locked. Counter is changed by atomic update so parallel attempts are all counted.
+func registerLoginFailure(db *gorm.DB, key string, maxAttempts int, policy loginThrottlePolicy) bool {
+	now := time.Now()
+
+	// Make sure the counter exist, row created by parallel attempt is kept
+	db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.USR_LoginThrottle{Key: key})
+
+	// Counter start again when the last failure already outside lockout window
+	db.Model(&models.USR_LoginThrottle{}).
+		Where("`key` = ? AND last_failure_at < ?", key, now.Add(-policy.LockoutDuration)).
+		Update("failures", 0)
+
+	db.Model(&models.USR_LoginThrottle{}).
+		Where("`key` = ?", key).
+		Updates(map[string]interface{}{"failures": gorm.Expr("failures + 1"), "last_failure_at": now})
+
+	var throttle models.USR_LoginThrottle
+	db.Limit(1).Where("`key` = ?", key).Find(&throttle)
+
+	delay := time.Duration(float64(policy.DelayBase) * math.Pow(2, float64(throttle.Failures-1)))
+	if delay > policy.DelayMax || delay <= 0 {
+		delay = policy.DelayMax
+	}
+	db.Model(&models.USR_LoginThrottle{}).Where("`key` = ?", key).Update("next_attempt_at", now.Add(delay))
+
+	// Only the attempt that reset the counter report the lockout
+	result := db.Model(&models.USR_LoginThrottle{}).
+		Where("`key` = ? AND failures >= ?", key, maxAttempts).
+		Updates(map[string]interface{}{"failures": 0, "locked_until": now.Add(policy.LockoutDuration)})
+
+	return result.Error == nil && result.RowsAffected != 0
+}
+
+// Register failed login for the account key and client ip, lockout is written into system log
+func registerFailedLogin(db *gorm.DB, c *gin.Context, accountKey string, usernameOrEmail string, log handlers.Log) {
+	policy := getLoginThrottlePolicy()
+
+	lockedKeys := map[string]int{}
+	if registerLoginFailure(db, accountKey, policy.MaxAccountAttempts, policy) {
+		lockedKeys[accountKey] = policy.MaxAccountAttempts
+	}
+	if registerLoginFailure(db, ipThrottleKey(c.ClientIP()), policy.MaxIPAttempts, policy) {
+		lockedKeys[ipThrottleKey(c.ClientIP())] = policy.MaxIPAttempts
+	}
+
+	for key, attempts := range lockedKeys {
+		handlers.LogSystem(handlers.LogSystemParam{
+			Identifier: c.GetString("X-Request-ID"),
+			StatusCode: http.StatusTooManyRequests,
+			Location:   log.Location,
+			Message:    fmt.Sprintf("Login locked for %s after %d failed attempts", key, attempts),
+			StartTime:  log.StartTime,
+			EndTime:    time.Now(),
+			UserInfo:   dtos.LogUserInfo{Username: usernameOrEmail},
+			Err:        fmt.Sprintf("Locked until %s", time.Now().Add(policy.LockoutDuration).Format(time.RFC3339)),
+		})
+	}
+}
+
+// Clear failure counter of an account after successful login or unlocked by admin
+func clearLoginThrottle(db *gorm.DB, keys ...string) error {
+	return db.Unscoped().Where("`key` IN ?", keys).Delete(&models.USR_LoginThrottle{}).Error
+}
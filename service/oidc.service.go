@@ -0,0 +1,360 @@
+package service
+
+import (
+	"fmt"
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Lifetime of pending OIDC login
+const oidcStateDuration = 10 * time.Minute
+
+// OIDCService defines the methods for login through external OpenID Connect identity provider.
+type OIDCService interface {
+	Login(c *gin.Context) handlers.ServiceResponseWithLogging
+	Callback(c *gin.Context) handlers.ServiceResponseWithLogging
+}
+
+// OIDCServiceImpl is the implementation of the OIDCService interface.
+type OIDCServiceImpl struct {
+	db       *gorm.DB
+	notifier helpers.Notifier
+}
+
+// OIDCServiceConstructor creates a new instance of OIDCServiceImpl.
+func OIDCServiceConstructor(db *gorm.DB, notifier helpers.Notifier) OIDCService {
+	return &OIDCServiceImpl{db: db, notifier: notifier}
+}
+
+// Find user for the external identity. User is matched by subject first, then by verified email
+// when linking by email is enabled (the subject is linked for next login). Administrative user is
+// never linked by email, since anyone controlling the email at the identity provider would take
+// over the account. Unknown identity is provisioned when enabled.
+func (o *OIDCServiceImpl) resolveUser(config helpers.OIDCConfig, claims *helpers.OIDCClaims) (models.USR_User, error) {
+	var user models.USR_User
+
+	result := o.db.Limit(1).Where("oidc_subject = ?", claims.Subject).Find(&user)
+	if result.Error != nil {
+		return user, result.Error
+	}
+	if result.RowsAffected != 0 {
+		return user, nil
+	}
+
+	if claims.Email != "" && claims.EmailVerified {
+		result = helpers.PreloadUserRoles(o.db).Limit(1).Where("email = ?", claims.Email).Find(&user)
+		if result.Error != nil {
+			return user, result.Error
+		}
+		if result.RowsAffected != 0 {
+			// Local account with the email exist, it is only linked when allowed
+			if !config.LinkByEmail || user.OIDCSubject != nil || user.IsServiceAccount || helpers.IsUserAdministrative(user) {
+				return models.USR_User{}, gorm.ErrRecordNotFound
+			}
+
+			result = o.db.Model(&models.USR_User{}).Where("id = ? AND oidc_subject IS NULL", user.ID).Update("oidc_subject", claims.Subject)
+			if result.Error == nil && result.RowsAffected == 0 {
+				return models.USR_User{}, gorm.ErrRecordNotFound
+			}
+			return user, result.Error
+		}
+	}
+
+	if !config.AutoProvision || config.DefaultRoleID == 0 || claims.Email == "" || !claims.EmailVerified {
+		return user, gorm.ErrRecordNotFound
+	}
+
+	username, err := o.availableUsername(claims)
+	if err != nil {
+		return user, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = username
+	}
+
+	subject := claims.Subject
+	user = models.USR_User{
+		Username:    username,
+		Name:        name,
+		Email:       claims.Email,
+		RoleID:      config.DefaultRoleID,
+		Roles:       []*models.USR_Role{{ID: config.DefaultRoleID}},
+		OIDCSubject: &subject,
+	}
+
+	err = o.db.Omit("Roles.*").Create(&user).Error
+	return user, err
+}
+
+// Pick username for provisioned user from preferred username or email, suffixed when taken
+func (o *OIDCServiceImpl) availableUsername(claims *helpers.OIDCClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base = strings.Split(claims.Email, "@")[0]
+	}
+	base = strings.Join(strings.Fields(base), "")
+	if len(base) < 3 {
+		base = "user-" + base
+	}
+
+	for i := 0; i < 100; i++ {
+		username := base
+		if i != 0 {
+			username = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		var count int64
+		if err := o.db.Model(&models.USR_User{}).Unscoped().Where("username = ?", username).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return username, nil
+		}
+	}
+
+	return "", fmt.Errorf("unable to find available username for %s", base)
+}
+
+// Login start OIDC authorization code flow with PKCE, client should redirect user to the returned url.
+func (o *OIDCServiceImpl) Login(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, o)
+
+	config := helpers.GetOIDCConfig()
+	if !config.Enabled() {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "OIDC login is not configured",
+			Data:    nil,
+			Err:     "OIDC login is not configured",
+			Log:     log,
+		}
+	}
+
+	provider, err := helpers.GetOIDCProvider(config)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadGateway,
+			Message: "Failed to reach identity provider",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	state, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to start OIDC login",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+	nonce, err := helpers.GenerateRandomToken(16)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to start OIDC login",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+	codeVerifier, codeChallenge, err := helpers.GeneratePKCE()
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to start OIDC login",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	expiresAt := time.Now().Add(oidcStateDuration)
+
+	// Remove abandoned login before saving the new one
+	o.db.Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.USR_OIDCState{})
+	if err := o.db.Create(&models.USR_OIDCState{
+		StateHash:    helpers.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    expiresAt,
+	}).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Creating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Redirect To Identity Provider",
+		Data: dtos.OIDCAuthorizationDTO{
+			AuthorizationURL: helpers.BuildOIDCAuthorizationURL(config, provider, state, nonce, codeChallenge),
+			ExpiresAt:        expiresAt,
+		},
+		Err: nil,
+		Log: log,
+	}
+}
+
+// Callback finish OIDC login, code is exchanged and id token verified before the user get
+// the normal e-procurement token.
+func (o *OIDCServiceImpl) Callback(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, o)
+	var input dtos.InputOIDCCallbackDTO
+
+	if err := c.ShouldBind(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	config := helpers.GetOIDCConfig()
+	if !config.Enabled() {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "OIDC login is not configured",
+			Data:    nil,
+			Err:     "OIDC login is not configured",
+			Log:     log,
+		}
+	}
+
+	// Check state existence, state is deleted right away so it can only be used once
+	var state models.USR_OIDCState
+	result := o.db.Limit(1).Where("state_hash = ?", helpers.HashToken(input.State)).Find(&state)
+	if result.Error == nil && result.RowsAffected != 0 {
+		result = o.db.Unscoped().Where("id = ?", state.ID).Delete(&models.USR_OIDCState{})
+	}
+	if result.Error != nil || result.RowsAffected == 0 || state.ExpiresAt.Before(time.Now()) {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid or expired OIDC state",
+			Data:    nil,
+			Err:     "Invalid or expired OIDC state",
+			Log:     log,
+		}
+	}
+
+	provider, err := helpers.GetOIDCProvider(config)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadGateway,
+			Message: "Failed to reach identity provider",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	idToken, err := helpers.ExchangeOIDCCode(config, provider, input.Code, state.CodeVerifier)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Failed to exchange authorization code",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	claims, err := helpers.VerifyOIDCIDToken(config, provider, idToken, state.Nonce)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid identity token",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	resolved, err := o.resolveUser(config, claims)
+	if err == gorm.ErrRecordNotFound {
+		recordLoginAttempt(o.db, c, o.notifier, models.USR_User{}, claims.Subject, models.LoginMethodOIDC, models.LoginReasonUnknownIdentity, log)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusForbidden,
+			Message: "No user registered for this identity",
+			Data:    nil,
+			Err:     fmt.Sprintf("No user for subject %s", claims.Subject),
+			Log:     log,
+		}
+	}
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to resolve user",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	var user models.USR_User
+	if err := helpers.PreloadUserRoles(o.db).First(&user, resolved.ID).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to resolve user",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Service account only authenticate using api key
+	if user.IsServiceAccount {
+		recordLoginAttempt(o.db, c, o.notifier, user, claims.Subject, models.LoginMethodOIDC, models.LoginReasonInvalidCredentials, log)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusForbidden,
+			Message: "No user registered for this identity",
+			Data:    nil,
+			Err:     "Service account can't login",
+			Log:     log,
+		}
+	}
+
+	if message := inactiveUserMessage(user); message != "" {
+		recordLoginAttempt(o.db, c, o.notifier, user, claims.Subject, models.LoginMethodOIDC, models.LoginReasonInactive, log)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusForbidden,
+			Message: message,
+			Data:    nil,
+			Err:     fmt.Sprintf("User status is %s", user.Status),
+			Log:     log,
+		}
+	}
+
+	return finishLogin(o.db, o.notifier, c, user, claims.Subject, models.LoginMethodOIDC, log)
+}
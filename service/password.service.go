@@ -0,0 +1,78 @@
+package service
+
+import (
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Check new password of user against password policy and password history,
+// returned message is empty when the password is acceptable
+func validateNewPassword(db *gorm.DB, user models.USR_User, password string) string {
+	policy := helpers.GetPasswordPolicy()
+
+	if err := policy.Validate(password, user.Username, user.Email, user.Name); err != nil {
+		return err.Error()
+	}
+
+	// New user doesn't have history yet
+	if user.ID == 0 {
+		return ""
+	}
+
+	reused, err := policy.IsReused(db, user, password)
+	if err != nil {
+		return "Failed to check password history"
+	}
+	if reused {
+		return "Password has been used recently, choose another password"
+	}
+
+	return ""
+}
+
+// Hash and save new password of user, the hash is kept in password history for reuse check.
+// User that not created yet is created with the password.
+func saveUserPassword(tx *gorm.DB, user *models.USR_User, password string) error {
+	hashedPassword, err := helpers.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+
+	if user.ID == 0 {
+		err = tx.Omit("Roles.*").Create(user).Error // Roles only need the join row
+	} else {
+		err = tx.Model(user).Updates(map[string]interface{}{"password": user.Password, "password_changed_at": now}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	return helpers.GetPasswordPolicy().RecordHistory(tx, user.ID, user.Password)
+}
+
+// Hash the password again with the configured hasher when the current hash use older algorithm
+// or parameter, only called with password that already verified. Password age is not changed.
+func rehashPasswordIfNeeded(db *gorm.DB, user *models.USR_User, password string) error {
+	if !helpers.PasswordNeedsRehash(user.Password) {
+		return nil
+	}
+
+	hashedPassword, err := helpers.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Model(&models.USR_User{}).Where("id = ? AND password = ?", user.ID, user.Password).UpdateColumn("password", hashedPassword).Error; err != nil {
+		return err
+	}
+
+	user.Password = hashedPassword
+	return nil
+}
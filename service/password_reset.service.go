@@ -0,0 +1,332 @@
+package service
+
+import (
+	"fmt"
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PasswordResetService defines the methods for the self-service password reset service.
+type PasswordResetService interface {
+	ForgotPassword(c *gin.Context) handlers.ServiceResponseWithLogging
+	ResetPassword(c *gin.Context) handlers.ServiceResponseWithLogging
+	ChangeExpiredPassword(c *gin.Context) handlers.ServiceResponseWithLogging
+}
+
+// PasswordResetServiceImpl is the implementation of the PasswordResetService interface.
+type PasswordResetServiceImpl struct {
+	db     *gorm.DB
+	mailer helpers.MailSender
+}
+
+// PasswordResetServiceConstructor creates a new instance of PasswordResetServiceImpl.
+func PasswordResetServiceConstructor(db *gorm.DB, mailer helpers.MailSender) PasswordResetService {
+	return &PasswordResetServiceImpl{db: db, mailer: mailer}
+}
+
+var errInvalidNewPassword = fmt.Errorf("new password is invalid")
+
+// Validate new password and its confirmation against password policy
+func (p *PasswordResetServiceImpl) newPasswordValidator(user models.USR_User, password string, rePassword string) (map[string]map[string]string, bool) {
+	errors := map[string]map[string]string{"errors": {}}
+
+	// Check if password and re-password is identical
+	if password != rePassword {
+		errors["errors"]["password"] = "Re-Password and Password are different"
+		errors["errors"]["re_password"] = "Re-Password and Password are different"
+	} else if message := validateNewPassword(p.db, user, password); message != "" {
+		errors["errors"]["password"] = message
+	}
+
+	return errors, len(errors["errors"]) != 0
+}
+
+// Create reset token for the user and send it by email, previous unused token is invalidated
+func (p *PasswordResetServiceImpl) sendResetToken(user models.USR_User) error {
+	lifetime := time.Duration(helpers.GetENVIntWithDefault("PASSWORD_RESET_TIME", 30)) * time.Minute
+
+	token, err := createUserToken(p.db, user.ID, models.UserTokenPurposePasswordReset, lifetime)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", helpers.GetENVWithDefault("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"), token)
+	body := fmt.Sprintf("Hello %s,\r\n\r\nWe received a request to reset your password. Open the link below to choose a new password:\r\n\r\n%s\r\n\r\nThe link expires in %d minutes and can only be used once. If you didn't request this, you can ignore this email.\r\n",
+		user.Name, link, int(lifetime.Minutes()))
+
+	return p.mailer.Send(user.Email, "Password Reset Request", body)
+}
+
+// ForgotPassword send password reset link to the email. Response is the same whether the
+// account exist or not and the lookup is done in background so timing doesn't tell either.
+func (p *PasswordResetServiceImpl) ForgotPassword(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, p)
+	var input dtos.InputForgotPasswordDTO
+
+	if err := c.ShouldBind(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	requestID := c.GetString("X-Request-ID")
+	go func() {
+		var user models.USR_User
+		result := p.db.Limit(1).Where("email = ? AND is_service_account = ?", input.Email, false).Find(&user)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return
+		}
+
+		if err := p.sendResetToken(user); err != nil {
+			handlers.LogSystem(handlers.LogSystemParam{
+				Identifier: requestID,
+				StatusCode: http.StatusInternalServerError,
+				Location:   log.Location,
+				Message:    "Failed to send password reset email",
+				StartTime:  log.StartTime,
+				EndTime:    time.Now(),
+				UserInfo:   dtos.LogUserInfo{ID: fmt.Sprint(user.ID), Username: user.Username},
+				Err:        err.Error(),
+			})
+		}
+	}()
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "If the email is registered, password reset instruction has been sent to it",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// ResetPassword set new password using token from forgot password email.
+func (p *PasswordResetServiceImpl) ResetPassword(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, p)
+	var input dtos.InputResetPasswordDTO
+
+	if err := c.ShouldBind(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	// Token is consumed together with the password change, invalid new password roll back the
+	// transaction so the token can still be used
+	var user models.USR_User
+	var validationErrors map[string]map[string]string
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		userToken, err := consumeUserToken(tx, input.Token, models.UserTokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Limit(1).Where("id = ?", userToken.UserID).Find(&user)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if errors, invalid := p.newPasswordValidator(user, input.Password, input.RePassword); invalid {
+			validationErrors = errors
+			return errInvalidNewPassword
+		}
+
+		if err := saveUserPassword(tx, &user, input.Password); err != nil {
+			return err
+		}
+
+		return helpers.RevokeUserTokens(tx, user.ID, "Password reset by user")
+	})
+	if err == errInvalidNewPassword {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Data",
+			Data:    nil,
+			Err:     validationErrors,
+			Log:     log,
+		}
+	}
+	if err == gorm.ErrRecordNotFound {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid or expired reset token",
+			Data:    nil,
+			Err:     "Invalid or expired reset token",
+			Log:     log,
+		}
+	}
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Updating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Owner of the email proved the access, so lift lockout of the account
+	clearLoginThrottle(p.db, userThrottleKey(user.ID))
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Password Reset Successfully",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// ChangeExpiredPassword set new password using challenge token given by login when the
+// password already expired, user must login again with the new password afterward.
+func (p *PasswordResetServiceImpl) ChangeExpiredPassword(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, p)
+	var input dtos.InputChangeExpiredPasswordDTO
+
+	if err := c.ShouldBind(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	// Check challenge token validity, challenge token is single use
+	claims, err := helpers.ParseChallengeToken(input.ChallengeToken, helpers.ChallengePurposePasswordChange)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid or expired challenge token",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+	if revoked, err := helpers.IsTokenRevoked(p.db, claims.Id); err != nil || revoked {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid or expired challenge token",
+			Data:    nil,
+			Err:     "Challenge token already used",
+			Log:     log,
+		}
+	}
+
+	var user models.USR_User
+	result := p.db.Limit(1).Where("id = ?", claims.UserID).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid or expired challenge token",
+			Data:    nil,
+			Err:     "User not found",
+			Log:     log,
+		}
+	}
+
+	if errors, invalid := p.newPasswordValidator(user, input.Password, input.RePassword); invalid {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	err = p.db.Transaction(func(tx *gorm.DB) error {
+		// Revoke the challenge first, so concurrent request with the same challenge can't change
+		// the password again
+		consumed, err := helpers.ConsumeToken(tx, claims.Id, user.ID, time.Unix(claims.ExpiresAt, 0), "Password changed")
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return gorm.ErrRecordNotFound
+		}
+
+		return saveUserPassword(tx, &user, input.Password)
+	})
+	if err == gorm.ErrRecordNotFound {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid or expired challenge token",
+			Data:    nil,
+			Err:     "Challenge token already used",
+			Log:     log,
+		}
+	}
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Updating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Password Changed Successfully, Please Login Again",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
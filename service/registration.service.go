@@ -0,0 +1,311 @@
+package service
+
+import (
+	"fmt"
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RegistrationService defines the methods for the vendor self-registration service.
+type RegistrationService interface {
+	Register(c *gin.Context) handlers.ServiceResponseWithLogging
+	VerifyEmail(c *gin.Context) handlers.ServiceResponseWithLogging
+	ResendVerification(c *gin.Context) handlers.ServiceResponseWithLogging
+}
+
+// RegistrationServiceImpl is the implementation of the RegistrationService interface.
+type RegistrationServiceImpl struct {
+	db     *gorm.DB
+	mailer helpers.MailSender
+}
+
+// RegistrationServiceConstructor creates a new instance of RegistrationServiceImpl.
+func RegistrationServiceConstructor(db *gorm.DB, mailer helpers.MailSender) RegistrationService {
+	return &RegistrationServiceImpl{db: db, mailer: mailer}
+}
+
+// Validate user input that validator cannot check by validator v10 package
+func (r *RegistrationServiceImpl) inputValidator(input dtos.InputRegisterDTO, c *gin.Context) (map[string]map[string]string, bool) {
+	errors := map[string]map[string]string{"errors": {}}
+	log := helpers.CreateLog(c, r)
+
+	// Check username and email duplication
+	var duplicate models.USR_User
+	if result := r.db.Limit(1).Where("username = ?", input.Username).Find(&duplicate); result.Error != nil || result.RowsAffected >= 1 {
+		errors["errors"]["username"] = fmt.Sprintf("Username %s already exist", input.Username)
+	}
+	if result := r.db.Limit(1).Where("email = ?", input.Email).Find(&duplicate); result.Error != nil || result.RowsAffected >= 1 {
+		errors["errors"]["email"] = fmt.Sprintf("User email %s already exist", input.Email)
+	}
+
+	// Check if password and re-password is identical and follow password policy
+	if input.Password != input.RePassword {
+		errors["errors"]["password"] = "Re-Password and Password are different"
+		errors["errors"]["re_password"] = "Re-Password and Password are different"
+	} else if message := validateNewPassword(r.db, models.USR_User{Username: input.Username, Name: input.Name, Email: input.Email}, input.Password); message != "" {
+		errors["errors"]["password"] = message
+	}
+
+	isError := len(errors["errors"]) != 0
+	if isError {
+		handlers.WriteLog(c, http.StatusBadRequest, "Validation errors encountered", errors, log)
+	} else {
+		handlers.WriteLog(c, http.StatusProcessing, "Validation passed, continuing", nil, log)
+	}
+
+	return errors, isError
+}
+
+// Create email verification token for the user and send it by email
+func (r *RegistrationServiceImpl) sendVerification(user models.USR_User) error {
+	lifetime := time.Duration(helpers.GetENVIntWithDefault("EMAIL_VERIFICATION_TIME", 24)) * time.Hour
+
+	token, err := createUserToken(r.db, user.ID, models.UserTokenPurposeEmailVerification, lifetime)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", helpers.GetENVWithDefault("EMAIL_VERIFICATION_URL", "http://localhost:3000/verify-email"), token)
+	body := fmt.Sprintf("Hello %s,\r\n\r\nThank you for registering as vendor. Open the link below to verify your email:\r\n\r\n%s\r\n\r\nThe link expires in %d hours. After your email is verified, your account will be reviewed by our officer before you can login.\r\n",
+		user.Name, link, int(lifetime.Hours()))
+
+	return r.mailer.Send(user.Email, "Verify Your Email", body)
+}
+
+// Send verification email in background, failure is only logged
+func (r *RegistrationServiceImpl) sendVerificationAsync(c *gin.Context, user models.USR_User, log handlers.Log) {
+	requestID := c.GetString("X-Request-ID")
+	go func() {
+		if err := r.sendVerification(user); err != nil {
+			handlers.LogSystem(handlers.LogSystemParam{
+				Identifier: requestID,
+				StatusCode: http.StatusInternalServerError,
+				Location:   log.Location,
+				Message:    "Failed to send email verification",
+				StartTime:  log.StartTime,
+				EndTime:    time.Now(),
+				UserInfo:   dtos.LogUserInfo{ID: fmt.Sprint(user.ID), Username: user.Username},
+				Err:        err.Error(),
+			})
+		}
+	}()
+}
+
+// Register create vendor account waiting for email verification.
+func (r *RegistrationServiceImpl) Register(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, r)
+	var input dtos.InputRegisterDTO
+
+	if err := c.ShouldBind(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	// Check and validate input that cannot be validate by golang validator
+	if errors, errorHappen := r.inputValidator(input, c); errorHappen {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	// Registered user always get vendor role
+	var role models.USR_Role
+	result := r.db.Limit(1).Where("name = ?", helpers.GetENVWithDefault("VENDOR_ROLE_NAME", "Vendor")).Find(&role)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Vendor registration is not available",
+			Data:    nil,
+			Err:     "Vendor role not found",
+			Log:     log,
+		}
+	}
+
+	user := models.USR_User{
+		Username: input.Username,
+		Name:     input.Name,
+		Email:    input.Email,
+		RoleID:   role.ID,
+		Roles:    []*models.USR_Role{&role},
+		Status:   models.UserStatusPendingVerification,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return saveUserPassword(tx, &user, input.Password)
+	})
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Creating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	r.sendVerificationAsync(c, user, log)
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusCreated,
+		Message: "Registration Successful, Please Check Your Email To Verify Your Account",
+		Data:    dtos.ToUSRUserMinimalDTO(user),
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+var errEmailNotPendingVerification = fmt.Errorf("account is not pending verification")
+
+// VerifyEmail mark user email as verified, the account then wait for approval by officer.
+func (r *RegistrationServiceImpl) VerifyEmail(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, r)
+	var input dtos.InputVerifyEmailDTO
+
+	if err := c.ShouldBind(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		userToken, err := consumeUserToken(tx, input.Token, models.UserTokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		// Only pending verification account can be verified, status might be changed since token was sent
+		result := tx.Model(&models.USR_User{}).
+			Where("id = ? AND status = ?", userToken.UserID, models.UserStatusPendingVerification).
+			Updates(map[string]interface{}{"status": models.UserStatusPendingApproval, "email_verified_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errEmailNotPendingVerification
+		}
+
+		return nil
+	})
+	if err == errEmailNotPendingVerification {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusConflict,
+			Message: "Email already verified or account status changed",
+			Data:    nil,
+			Err:     "Account is not waiting for email verification",
+			Log:     log,
+		}
+	}
+	if err == gorm.ErrRecordNotFound {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid or expired verification token",
+			Data:    nil,
+			Err:     "Invalid or expired verification token",
+			Log:     log,
+		}
+	}
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Updating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Email Verified Successfully, Your Account Is Waiting For Approval",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// ResendVerification send new verification email. Response is the same whether the account
+// exist or not.
+func (r *RegistrationServiceImpl) ResendVerification(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, r)
+	var input dtos.InputResendVerificationDTO
+
+	if err := c.ShouldBind(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	var user models.USR_User
+	result := r.db.Limit(1).Where("email = ? AND status = ?", input.Email, models.UserStatusPendingVerification).Find(&user)
+	if result.Error == nil && result.RowsAffected != 0 {
+		r.sendVerificationAsync(c, user, log)
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "If the email is waiting for verification, new verification link has been sent to it",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RoutePermissionService defines the methods for inspecting features required by routes.
+type RoutePermissionService interface {
+	GetAll(c *gin.Context) handlers.ServiceResponseWithLogging
+}
+
+// RoutePermissionServiceImpl is the implementation of the RoutePermissionService interface.
+type RoutePermissionServiceImpl struct {
+	db *gorm.DB
+}
+
+// RoutePermissionServiceConstructor creates a new instance of RoutePermissionServiceImpl.
+func RoutePermissionServiceConstructor(db *gorm.DB) RoutePermissionService {
+	return &RoutePermissionServiceImpl{db: db}
+}
+
+// GetAll list every protected route with its required features, flagging features used by
+// route that don't exist and features that no route use.
+func (r *RoutePermissionServiceImpl) GetAll(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, r)
+
+	missing, err := helpers.GetMissingRouteFeatures(r.db)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Getting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	var unused []models.USR_Feature
+	query := r.db.Order("code")
+	if codes := helpers.GetRouteFeatureCodes(); len(codes) != 0 {
+		query = query.Where("code NOT IN ?", codes)
+	}
+	if err := query.Find(&unused).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Getting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	routes := helpers.GetRoutePermissions()
+	routeDTOs := make([]dtos.RoutePermissionDTO, len(routes))
+	for i, route := range routes {
+		routeDTOs[i] = dtos.RoutePermissionDTO{
+			Method:      route.Method,
+			Path:        route.Path,
+			Features:    route.Features,
+			IsAdminOnly: route.IsAdminOnly,
+		}
+	}
+
+	unusedDTOs := dtos.ToUSRFeatureMinimalDTOs(unused)
+	if unusedDTOs == nil {
+		unusedDTOs = []dtos.USRFeatureMinimalDTO{}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Success Getting Route Permission Data",
+		Data: dtos.RoutePermissionReportDTO{
+			Routes:          routeDTOs,
+			MissingFeatures: missing,
+			UnusedFeatures:  unusedDTOs,
+		},
+		Err: nil,
+		Log: log,
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"fmt"
+	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Create new session (token family) for the user and issue the first access and refresh token
+func createSession(db *gorm.DB, c *gin.Context, user models.USR_User) (dtos.TokenDTO, error) {
+	var tokens dtos.TokenDTO
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		session := models.USR_Session{
+			UserID:    user.ID,
+			UserAgent: c.Request.UserAgent(),
+			ClientIP:  c.ClientIP(),
+			ExpiresAt: time.Now().Add(helpers.GetRefreshTokenDuration()),
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		tokens, err = issueSessionTokens(tx, user, &session)
+		return err
+	})
+
+	return tokens, err
+}
+
+// Create session of impersonation, only an access token with actor claim is issued (no refresh
+// token) so it end after IMPERSONATION_TIME minutes
+func createImpersonationSession(db *gorm.DB, c *gin.Context, user models.USR_User, actor models.USR_User) (string, *dtos.Claims, error) {
+	lifetime := time.Duration(helpers.GetENVIntWithDefault("IMPERSONATION_TIME", 30)) * time.Minute
+
+	var (
+		token  string
+		claims *dtos.Claims
+	)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		session := models.USR_Session{
+			UserID:         user.ID,
+			UserAgent:      c.Request.UserAgent(),
+			ClientIP:       c.ClientIP(),
+			ExpiresAt:      time.Now().Add(lifetime),
+			ImpersonatorID: &actor.ID,
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		// Token carry features inherited from parent roles as well
+		if err := helpers.InheritUserRoleFeatures(tx, &user); err != nil {
+			return err
+		}
+
+		var err error
+		token, claims, err = helpers.GenerateImpersonationJWT(user, session.ID, dtos.ActorClaims{UserID: actor.ID, Username: actor.Username}, lifetime)
+		return err
+	})
+
+	return token, claims, err
+}
+
+// Issue access token and a new refresh token that belong to the given session,
+// session expiry is extended following the new refresh token expiry
+func issueSessionTokens(tx *gorm.DB, user models.USR_User, session *models.USR_Session) (dtos.TokenDTO, error) {
+	refreshToken, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return dtos.TokenDTO{}, err
+	}
+
+	// Token carry features inherited from parent roles as well
+	if err := helpers.InheritUserRoleFeatures(tx, &user); err != nil {
+		return dtos.TokenDTO{}, err
+	}
+
+	token, claims, err := helpers.GenerateJWT(user, session.ID)
+	if err != nil {
+		return dtos.TokenDTO{}, err
+	}
+
+	refreshExpiresAt := time.Now().Add(helpers.GetRefreshTokenDuration())
+	refreshTokenModel := models.USR_RefreshToken{
+		SessionID:            session.ID,
+		TokenHash:            helpers.HashToken(refreshToken),
+		ExpiresAt:            refreshExpiresAt,
+		AccessTokenID:        claims.Id,
+		AccessTokenExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}
+	if err := tx.Create(&refreshTokenModel).Error; err != nil {
+		return dtos.TokenDTO{}, err
+	}
+
+	if err := tx.Model(session).Update("expires_at", refreshExpiresAt).Error; err != nil {
+		return dtos.TokenDTO{}, err
+	}
+
+	return dtos.TokenDTO{
+		Token:            fmt.Sprintf("Bearer %s", token),
+		ExpiresAt:        time.Unix(claims.ExpiresAt, 0),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// Revoke session and by that every refresh token in the token family
+func revokeSession(db *gorm.DB, sessionID uint, reason string) error {
+	return db.Model(&models.USR_Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+}
@@ -0,0 +1,483 @@
+package service
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Amount of recovery codes generated on activation
+const recoveryCodeCount = 10
+
+// TwoFactorService defines the methods for the two factor authentication service.
+type TwoFactorService interface {
+	Verify(c *gin.Context) handlers.ServiceResponseWithLogging
+	Enroll(c *gin.Context) handlers.ServiceResponseWithLogging
+	Activate(c *gin.Context) handlers.ServiceResponseWithLogging
+	Disable(c *gin.Context) handlers.ServiceResponseWithLogging
+}
+
+// TwoFactorServiceImpl is the implementation of the TwoFactorService interface.
+type TwoFactorServiceImpl struct {
+	db       *gorm.DB
+	notifier helpers.Notifier
+}
+
+// TwoFactorServiceConstructor creates a new instance of TwoFactorServiceImpl.
+func TwoFactorServiceConstructor(db *gorm.DB, notifier helpers.Notifier) TwoFactorService {
+	return &TwoFactorServiceImpl{db: db, notifier: notifier}
+}
+
+// Create challenge for user that must pass two factor authentication after password check,
+// user of role that require two factor but not enrolled yet get enrollment challenge
+func newTwoFactorChallenge(user models.USR_User) (dtos.TwoFactorChallengeDTO, error) {
+	purpose := helpers.ChallengePurposeTwoFactor
+	if !user.TOTPEnabled {
+		purpose = helpers.ChallengePurposeTwoFactorEnrollment
+	}
+
+	token, claims, err := helpers.GenerateChallengeToken(user.ID, purpose)
+	if err != nil {
+		return dtos.TwoFactorChallengeDTO{}, err
+	}
+
+	return dtos.TwoFactorChallengeDTO{
+		TwoFactorRequired:  true,
+		EnrollmentRequired: !user.TOTPEnabled,
+		ChallengeToken:     token,
+		ExpiresAt:          time.Unix(claims.ExpiresAt, 0),
+	}, nil
+}
+
+// Check code against TOTP secret or unused recovery codes, used code is consumed right away
+func (t *TwoFactorServiceImpl) consumeTwoFactorCode(user models.USR_User, code string) bool {
+	if step, valid := helpers.ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep); valid {
+		// The where clause make sure the same time step can't be used by concurrent request
+		result := t.db.Model(&models.USR_User{}).Where("id = ? AND totp_last_step < ?", user.ID, step).Update("totp_last_step", step)
+		return result.Error == nil && result.RowsAffected == 1
+	}
+
+	result := t.db.Model(&models.USR_RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, helpers.HashToken(helpers.NormalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+// Verify exchange challenge token and valid two factor code with access and refresh token.
+func (t *TwoFactorServiceImpl) Verify(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, t)
+	var input dtos.InputVerifyTwoFactorDTO
+
+	if err := c.ShouldBind(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	// Check challenge token validity, challenge token is single use
+	claims, err := helpers.ParseChallengeToken(input.ChallengeToken, helpers.ChallengePurposeTwoFactor)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid or expired challenge token",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+	if revoked, err := helpers.IsTokenRevoked(t.db, claims.Id); err != nil || revoked {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid or expired challenge token",
+			Data:    nil,
+			Err:     "Challenge token already used",
+			Log:     log,
+		}
+	}
+
+	var user models.USR_User
+	result := helpers.PreloadUserRoles(t.db).Limit(1).Where("id = ?", claims.UserID).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 || !user.TOTPEnabled {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "Invalid or expired challenge token",
+			Data:    nil,
+			Err:     "User not found or two factor not enabled",
+			Log:     log,
+		}
+	}
+
+	// Code guessing is throttled the same way as password guessing
+	if wait, allowed := checkLoginThrottle(t.db, userThrottleKey(user.ID), ipThrottleKey(c.ClientIP())); !allowed {
+		recordLoginAttempt(t.db, c, t.notifier, user, user.Username, models.LoginMethodTwoFactor, models.LoginReasonThrottled, log)
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusTooManyRequests,
+			Message: "Too many failed login attempts, try again later",
+			Data:    nil,
+			Err:     "Login throttled",
+			Log:     log,
+		}
+	}
+
+	if !t.consumeTwoFactorCode(user, input.Code) {
+		registerFailedLogin(t.db, c, userThrottleKey(user.ID), user.Username, log)
+		recordLoginAttempt(t.db, c, t.notifier, user, user.Username, models.LoginMethodTwoFactor, models.LoginReasonInvalidTwoFactor, log)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid two factor code",
+			Data:    nil,
+			Err:     "Invalid two factor code",
+			Log:     log,
+		}
+	}
+
+	clearLoginThrottle(t.db, userThrottleKey(user.ID))
+	helpers.RevokeToken(t.db, claims.Id, user.ID, time.Unix(claims.ExpiresAt, 0), "Challenge completed")
+
+	tokens, err := createSession(t.db, c, user)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Failed to generate token",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	recordLoginAttempt(t.db, c, t.notifier, user, user.Username, models.LoginMethodTwoFactor, models.LoginReasonSuccess, log)
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "User Login Successfully",
+		Data:    loginData(user, tokens),
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// Enroll generate new TOTP secret for the user, two factor is not enabled until activated.
+func (t *TwoFactorServiceImpl) Enroll(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, t)
+
+	var userPayload models.USR_User
+	helpers.GetUserPayload(c, &userPayload)
+
+	var user models.USR_User
+	result := t.db.Limit(1).Where("id = ?", userPayload.ID).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "User not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	if user.TOTPEnabled {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Two factor authentication already enabled",
+			Data:    nil,
+			Err:     "Two factor authentication already enabled",
+			Log:     log,
+		}
+	}
+
+	secret, err := helpers.GenerateTOTPSecret()
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to generate secret",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	if err := t.db.Model(&user).Updates(map[string]interface{}{"totp_secret": secret, "totp_last_step": 0}).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Updating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Two Factor Enrollment Started",
+		Data: dtos.TwoFactorEnrollmentDTO{
+			Secret:     secret,
+			OTPAuthURI: helpers.GenerateTOTPURI(secret, user.Email),
+		},
+		Err: nil,
+		Log: log,
+	}
+}
+
+// Activate enable two factor after user prove the authenticator app is set up, recovery codes
+// are returned once. When called with enrollment challenge the login is completed as well.
+func (t *TwoFactorServiceImpl) Activate(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, t)
+	var input dtos.InputTwoFactorCodeDTO
+
+	if err := c.ShouldBind(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	var userPayload models.USR_User
+	helpers.GetUserPayload(c, &userPayload)
+
+	var user models.USR_User
+	result := helpers.PreloadUserRoles(t.db).Limit(1).Where("id = ?", userPayload.ID).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "User not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	if user.TOTPEnabled || user.TOTPSecret == "" {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Two factor enrollment not started or already enabled",
+			Data:    nil,
+			Err:     "Invalid two factor state",
+			Log:     log,
+		}
+	}
+
+	step, valid := helpers.ValidateTOTP(user.TOTPSecret, input.Code, time.Now(), user.TOTPLastStep)
+	if !valid {
+		registerFailedLogin(t.db, c, userThrottleKey(user.ID), user.Username, log)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid two factor code",
+			Data:    nil,
+			Err:     "Invalid two factor code",
+			Log:     log,
+		}
+	}
+
+	recoveryCodes, err := helpers.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to generate recovery codes",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Enable two factor and replace recovery codes
+	err = t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{"totp_enabled": true, "totp_last_step": step}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.USR_RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]models.USR_RecoveryCode, len(recoveryCodes))
+		for i, code := range recoveryCodes {
+			codes[i] = models.USR_RecoveryCode{UserID: user.ID, CodeHash: helpers.HashToken(helpers.NormalizeRecoveryCode(code))}
+		}
+
+		return tx.Create(&codes).Error
+	})
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Updating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	data := map[string]interface{}{
+		"recovery_codes": recoveryCodes,
+	}
+
+	// Finish login that waiting for enrollment
+	if challenge, exist := c.Get("challenge"); exist {
+		if claims, ok := challenge.(*dtos.ChallengeClaims); ok {
+			helpers.RevokeToken(t.db, claims.Id, user.ID, time.Unix(claims.ExpiresAt, 0), "Challenge completed")
+
+			user.TOTPEnabled = true
+			tokens, err := createSession(t.db, c, user)
+			if err != nil {
+				return handlers.ServiceResponseWithLogging{
+					Status:  http.StatusBadRequest,
+					Message: "Failed to generate token",
+					Data:    nil,
+					Err:     err.Error(),
+					Log:     log,
+				}
+			}
+			recordLoginAttempt(t.db, c, t.notifier, user, user.Username, models.LoginMethodTwoFactor, models.LoginReasonSuccess, log)
+
+			for key, value := range loginData(user, tokens) {
+				data[key] = value
+			}
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Two Factor Authentication Enabled Successfully",
+		Data:    data,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// Disable turn off two factor for the user, not allowed when the role require it.
+func (t *TwoFactorServiceImpl) Disable(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, t)
+	var input dtos.InputTwoFactorCodeDTO
+
+	if err := c.ShouldBind(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	var userPayload models.USR_User
+	helpers.GetUserPayload(c, &userPayload)
+
+	var user models.USR_User
+	result := t.db.Preload("Role").Preload("Roles").Limit(1).Where("id = ?", userPayload.ID).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "User not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	if !user.TOTPEnabled {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Two factor authentication is not enabled",
+			Data:    nil,
+			Err:     "Two factor authentication is not enabled",
+			Log:     log,
+		}
+	}
+
+	if helpers.IsTwoFactorRequired(user) {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusForbidden,
+			Message: "Two factor authentication is required for your role",
+			Data:    nil,
+			Err:     "Role require two factor authentication",
+			Log:     log,
+		}
+	}
+
+	if !t.consumeTwoFactorCode(user, input.Code) {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid two factor code",
+			Data:    nil,
+			Err:     "Invalid two factor code",
+			Log:     log,
+		}
+	}
+
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{"totp_enabled": false, "totp_secret": "", "totp_last_step": 0}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.USR_RecoveryCode{}).Error
+	})
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Updating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Two Factor Authentication Disabled Successfully",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Create single-use token of the given purpose for user, previous unused token of the same
+// purpose is invalidated. Only the hash is stored, the token itself must be sent to the user.
+func createUserToken(db *gorm.DB, userID uint, purpose string, lifetime time.Duration) (string, error) {
+	token, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.USR_UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.USR_UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: helpers.HashToken(token),
+			ExpiresAt: time.Now().Add(lifetime),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Mark valid (unused and not expired) token as used and return it, gorm.ErrRecordNotFound is
+// returned when the token is invalid or already used by concurrent request
+func consumeUserToken(tx *gorm.DB, token string, purpose string) (models.USR_UserToken, error) {
+	var userToken models.USR_UserToken
+	result := tx.Limit(1).Where("token_hash = ? AND purpose = ?", helpers.HashToken(token), purpose).Find(&userToken)
+	if result.Error != nil {
+		return userToken, result.Error
+	}
+	if result.RowsAffected == 0 || userToken.UsedAt != nil || userToken.ExpiresAt.Before(time.Now()) {
+		return userToken, gorm.ErrRecordNotFound
+	}
+
+	result = tx.Model(&models.USR_UserToken{}).Where("id = ? AND used_at IS NULL", userToken.ID).Update("used_at", time.Now())
+	if result.Error != nil {
+		return userToken, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return userToken, gorm.ErrRecordNotFound
+	}
+
+	return userToken, nil
+}
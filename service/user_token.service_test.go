@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"jxb-eprocurement/models"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// In-memory usr_user_tokens table behind database/sql, it only understand the statements of
+// createUserToken and consumeUserToken so the token flow run through the real gorm query
+type userTokenStore struct {
+	sync.Mutex
+	rows   []map[string]driver.Value
+	nextID int64
+}
+
+var userTokenColumns = []string{"id", "user_id", "purpose", "token_hash", "expires_at", "used_at", "created_at", "updated_at", "deleted_at"}
+
+func (s *userTokenStore) Connect(context.Context) (driver.Conn, error) { return &userTokenConn{s}, nil }
+func (s *userTokenStore) Driver() driver.Driver                        { return nil }
+
+type userTokenConn struct{ store *userTokenStore }
+
+func (c *userTokenConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare is not supported: %s", query)
+}
+func (c *userTokenConn) Close() error              { return nil }
+func (c *userTokenConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *userTokenConn) Commit() error             { return nil }
+func (c *userTokenConn) Rollback() error           { return nil }
+
+var insertColumnsPattern = regexp.MustCompile("^INSERT INTO `usr_user_tokens` \\(([^)]*)\\)")
+
+func (c *userTokenConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.store.Lock()
+	defer c.store.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, "INSERT INTO `usr_user_tokens`"):
+		columns := strings.Split(strings.ReplaceAll(insertColumnsPattern.FindStringSubmatch(query)[1], "`", ""), ",")
+		c.store.nextID++
+		row := map[string]driver.Value{"id": c.store.nextID}
+		for i, column := range columns {
+			row[column] = args[i].Value
+		}
+		c.store.rows = append(c.store.rows, row)
+		return userTokenResult{lastID: c.store.nextID, affected: 1}, nil
+
+	case strings.HasPrefix(query, "UPDATE `usr_user_tokens` SET `used_at`=?,`updated_at`=? WHERE (id = ? AND used_at IS NULL)"):
+		return c.markUsed(args, func(row map[string]driver.Value) bool {
+			return row["id"] == args[2].Value
+		}), nil
+
+	case strings.HasPrefix(query, "UPDATE `usr_user_tokens` SET `used_at`=?,`updated_at`=? WHERE (user_id = ? AND purpose = ? AND used_at IS NULL)"):
+		return c.markUsed(args, func(row map[string]driver.Value) bool {
+			return row["user_id"] == args[2].Value && row["purpose"] == args[3].Value
+		}), nil
+	}
+	return nil, fmt.Errorf("unexpected statement: %s", query)
+}
+
+func (c *userTokenConn) markUsed(args []driver.NamedValue, match func(row map[string]driver.Value) bool) driver.Result {
+	var affected int64
+	for _, row := range c.store.rows {
+		if row["used_at"] == nil && row["deleted_at"] == nil && match(row) {
+			row["used_at"], row["updated_at"] = args[0].Value, args[1].Value
+			affected++
+		}
+	}
+	return userTokenResult{affected: affected}
+}
+
+func (c *userTokenConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.store.Lock()
+	defer c.store.Unlock()
+
+	if !strings.HasPrefix(query, "SELECT * FROM `usr_user_tokens` WHERE (token_hash = ? AND purpose = ?)") {
+		return nil, fmt.Errorf("unexpected query: %s", query)
+	}
+
+	rows := &userTokenRows{}
+	for _, row := range c.store.rows {
+		if row["token_hash"] == args[0].Value && row["purpose"] == args[1].Value && row["deleted_at"] == nil {
+			values := make([]driver.Value, len(userTokenColumns))
+			for i, column := range userTokenColumns {
+				values[i] = row[column]
+			}
+			rows.values = append(rows.values, values)
+		}
+	}
+	return rows, nil
+}
+
+type userTokenResult struct{ lastID, affected int64 }
+
+func (r userTokenResult) LastInsertId() (int64, error) { return r.lastID, nil }
+func (r userTokenResult) RowsAffected() (int64, error) { return r.affected, nil }
+
+type userTokenRows struct{ values [][]driver.Value }
+
+func (r *userTokenRows) Columns() []string { return userTokenColumns }
+func (r *userTokenRows) Close() error      { return nil }
+func (r *userTokenRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func newUserTokenDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sql.OpenDB(&userTokenStore{}), SkipInitializeWithVersion: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	return db
+}
+
+// MailSender that keep the email instead of sending it
+type memoryMailSender struct {
+	mails []memoryMail
+}
+
+type memoryMail struct {
+	To, Subject, Body string
+}
+
+func (m *memoryMailSender) Send(to string, subject string, body string) error {
+	m.mails = append(m.mails, memoryMail{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+var resetLinkTokenPattern = regexp.MustCompile(`\?token=([A-Za-z0-9_-]+)`)
+
+// Send reset email and get the token from its link
+func sendResetTokenByMail(t *testing.T, service *PasswordResetServiceImpl, mailer *memoryMailSender, user models.USR_User) string {
+	if err := service.sendResetToken(user); err != nil {
+		t.Fatalf("send reset token: %v", err)
+	}
+
+	mail := mailer.mails[len(mailer.mails)-1]
+	if mail.To != user.Email {
+		t.Fatalf("reset email sent to %s, want %s", mail.To, user.Email)
+	}
+	match := resetLinkTokenPattern.FindStringSubmatch(mail.Body)
+	if match == nil {
+		t.Fatalf("reset email has no token link: %q", mail.Body)
+	}
+	return match[1]
+}
+
+func TestResetTokenFromMailCanOnlyBeConsumedOnce(t *testing.T) {
+	db := newUserTokenDB(t)
+	mailer := &memoryMailSender{}
+	service := &PasswordResetServiceImpl{db: db, mailer: mailer}
+	user := models.USR_User{ID: 7, Name: "Vendor", Email: "vendor@example.com"}
+
+	token := sendResetTokenByMail(t, service, mailer, user)
+
+	userToken, err := consumeUserToken(db, token, models.UserTokenPurposePasswordReset)
+	if err != nil {
+		t.Fatalf("consume reset token: %v", err)
+	}
+	if userToken.UserID != user.ID {
+		t.Fatalf("token belong to user %d, want %d", userToken.UserID, user.ID)
+	}
+
+	if _, err := consumeUserToken(db, token, models.UserTokenPurposePasswordReset); err != gorm.ErrRecordNotFound {
+		t.Fatalf("consume used token: got %v, want record not found", err)
+	}
+}
+
+func TestResetTokenIsInvalidatedByNewerToken(t *testing.T) {
+	db := newUserTokenDB(t)
+	mailer := &memoryMailSender{}
+	service := &PasswordResetServiceImpl{db: db, mailer: mailer}
+	user := models.USR_User{ID: 7, Name: "Vendor", Email: "vendor@example.com"}
+
+	oldToken := sendResetTokenByMail(t, service, mailer, user)
+	newToken := sendResetTokenByMail(t, service, mailer, user)
+
+	if _, err := consumeUserToken(db, oldToken, models.UserTokenPurposePasswordReset); err != gorm.ErrRecordNotFound {
+		t.Fatalf("consume replaced token: got %v, want record not found", err)
+	}
+	if _, err := consumeUserToken(db, newToken, models.UserTokenPurposePasswordReset); err != nil {
+		t.Fatalf("consume newest token: %v", err)
+	}
+}
+
+func TestConsumeUserTokenRejectsInvalidToken(t *testing.T) {
+	db := newUserTokenDB(t)
+
+	token, err := createUserToken(db, 7, models.UserTokenPurposeEmailVerification, time.Hour)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	expired, err := createUserToken(db, 8, models.UserTokenPurposePasswordReset, -time.Minute)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	tests := map[string]struct {
+		token   string
+		purpose string
+	}{
+		"unknown token": {token: "unknown", purpose: models.UserTokenPurposePasswordReset},
+		"other purpose": {token: token, purpose: models.UserTokenPurposePasswordReset},
+		"expired token": {token: expired, purpose: models.UserTokenPurposePasswordReset},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := consumeUserToken(db, test.token, test.purpose); err != gorm.ErrRecordNotFound {
+				t.Fatalf("got %v, want record not found", err)
+			}
+		})
+	}
+
+	// Rejected attempt must not use up the token
+	if _, err := consumeUserToken(db, token, models.UserTokenPurposeEmailVerification); err != nil {
+		t.Fatalf("consume token: %v", err)
+	}
+}
@@ -43,16 +43,21 @@ func (m *FeatureServiceImpl) inputValidator(feature models.USR_Feature, method s
 	// Create log
 	log := helpers.CreateLog(c, m)
 
-	// Check name duplication
-	var duplicateName models.USR_Feature
-	if method == "POST" { // Check for POST method
-		result = m.db.Limit(1).Where("name = ?", feature.Name).Find(&duplicateName)
-	} else { // Check for PUT and PATCH method
-		result = m.db.Limit(1).Where("name = ?", feature.Name).Not("id = ?", feature.ID).Find(&duplicateName)
-	}
-	if result.Error != nil || result.RowsAffected >= 1 {
-		errors["errors"]["name"] = fmt.Sprintf("Module name %s already exist", feature.Name)
+	// Check code format and duplication, deleted feature still hold its code
+	if !helpers.IsValidFeatureCode(feature.Code) {
+		errors["errors"]["code"] = "Feature code must be lower snake case words separated by dot, e.g. user.reset_password"
 		is_error = true
+	} else {
+		var duplicateCode models.USR_Feature
+		if method == "POST" { // Check for POST method
+			result = m.db.Unscoped().Limit(1).Where("code = ?", feature.Code).Find(&duplicateCode)
+		} else { // Check for PUT and PATCH method
+			result = m.db.Unscoped().Limit(1).Where("code = ?", feature.Code).Not("id = ?", feature.ID).Find(&duplicateCode)
+		}
+		if result.Error != nil || result.RowsAffected >= 1 {
+			errors["errors"]["code"] = fmt.Sprintf("Feature code %s already exist", feature.Code)
+			is_error = true
+		}
 	}
 
 	// Check parent_id input validity
@@ -199,6 +204,25 @@ func (m *FeatureServiceImpl) AddData(c *gin.Context) handlers.ServiceResponseWit
 	}
 
 	feature := dtos.ToUSRFeatureMinimalModel(input)
+
+	// Feature without code get one generated from module and feature name
+	if feature.Code == "" {
+		var module models.USR_Module
+		m.db.Limit(1).Where("id = ?", feature.ModuleID).Find(&module)
+
+		code, err := helpers.GenerateUniqueFeatureCode(m.db, module.Name, feature.Name)
+		if err != nil {
+			return handlers.ServiceResponseWithLogging{
+				Status:  http.StatusInternalServerError,
+				Message: "Error Creating Data",
+				Data:    nil,
+				Err:     err.Error(),
+				Log:     log,
+			}
+		}
+		feature.Code = code
+	}
+
 	// Check and validate input that cannot be validate by golang validator
 	errors, errorHappen := m.inputValidator(feature, "POST", c)
 	if errorHappen {
@@ -222,6 +246,9 @@ func (m *FeatureServiceImpl) AddData(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
+	// Role granted the module of the feature hold the new feature right away
+	helpers.InvalidatePermissions()
+
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusCreated,
 		Message: "Module Created Successfully",
@@ -277,6 +304,18 @@ func (m *FeatureServiceImpl) UpdateData(c *gin.Context) handlers.ServiceResponse
 	// Parsing id params to input dto
 	input.ID = uint(id)
 
+	// Code is referenced by authorization check so it can't be changed once created
+	if input.Code != "" && input.Code != feature.Code {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     map[string]map[string]string{"errors": {"code": "Feature code can't be changed"}},
+			Log:     log,
+		}
+	}
+	input.Code = feature.Code
+
 	// Validate input using golang validator
 	if err := handlers.ValidateStruct(featureDTO); err != nil {
 		errors := handlers.ValidationErrorHandlerV1(c, err, featureDTO)
@@ -316,6 +355,9 @@ func (m *FeatureServiceImpl) UpdateData(c *gin.Context) handlers.ServiceResponse
 		}
 	}
 
+	// Feature could be held by any role, every cached permission is dropped
+	helpers.InvalidatePermissions()
+
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusOK,
 		Message: "Feature Updated Successfully",
@@ -374,6 +416,8 @@ func (m *FeatureServiceImpl) DeleteData(c *gin.Context) handlers.ServiceResponse
 		}
 	}
 
+	helpers.InvalidatePermissions()
+
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusOK,
 		Message: "Feature Deleted Successfully",
@@ -316,6 +316,9 @@ func (m *ModuleServiceImpl) UpdateData(c *gin.Context) handlers.ServiceResponseW
 		}
 	}
 
+	// Moving module change the features held through module grant
+	helpers.InvalidatePermissions()
+
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusOK,
 		Message: "Module Updated Successfully",
@@ -374,6 +377,9 @@ func (m *ModuleServiceImpl) DeleteData(c *gin.Context) handlers.ServiceResponseW
 		}
 	}
 
+	// Role granted the module or its ancestor lose the features
+	helpers.InvalidatePermissions()
+
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusOK,
 		Message: "Module Deleted Successfully",
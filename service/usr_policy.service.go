@@ -0,0 +1,527 @@
+package service
+
+import (
+	"fmt"
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PolicyService defines the methods for the attribute based access policy service.
+type PolicyService interface {
+	GetAll(c *gin.Context) handlers.ServiceResponseWithLogging
+	GetByID(c *gin.Context) handlers.ServiceResponseWithLogging
+	AddData(c *gin.Context) handlers.ServiceResponseWithLogging
+	UpdateData(c *gin.Context) handlers.ServiceResponseWithLogging
+	DeleteData(c *gin.Context) handlers.ServiceResponseWithLogging
+	DryRun(c *gin.Context) handlers.ServiceResponseWithLogging
+}
+
+// PolicyServiceImpl is the implementation of the PolicyService interface.
+type PolicyServiceImpl struct {
+	db *gorm.DB
+}
+
+// PolicyServiceConstructor creates a new instance of PolicyServiceImpl.
+func PolicyServiceConstructor(db *gorm.DB) PolicyService {
+	return &PolicyServiceImpl{db: db}
+}
+
+// Validate user input that validator cannot check for POST and PUT / PATCH method
+// method parameter option are: ["POST", "PUT", "PATCH"]
+func (p *PolicyServiceImpl) inputValidator(model models.USR_Policy, method string, c *gin.Context) (map[string]map[string]string, bool) {
+	errors := map[string]map[string]string{"errors": {}}
+	var result *gorm.DB
+
+	log := helpers.CreateLog(c, p)
+
+	// Check name duplication
+	var duplicateName models.USR_Policy
+	if method == "POST" {
+		result = p.db.Limit(1).Where("name = ?", model.Name).Find(&duplicateName)
+	} else {
+		result = p.db.Limit(1).Where("name = ?", model.Name).Not("id = ?", model.ID).Find(&duplicateName)
+	}
+	if result.Error != nil || result.RowsAffected >= 1 {
+		errors["errors"]["name"] = fmt.Sprintf("Policy name %s already exist", model.Name)
+	}
+
+	// Policy is attached to existing feature
+	var feature models.USR_Feature
+	if result := p.db.Limit(1).Where("code = ?", model.FeatureCode).Find(&feature); result.Error != nil || result.RowsAffected == 0 {
+		errors["errors"]["feature_code"] = fmt.Sprintf("Feature %s not found", model.FeatureCode)
+	}
+
+	// Expression is parsed on save so invalid rule never deny request silently
+	if _, err := helpers.ParsePolicyExpression(model.Expression); err != nil {
+		errors["errors"]["expression"] = err.Error()
+	}
+
+	isError := len(errors["errors"]) != 0
+	if isError {
+		handlers.WriteLog(c, http.StatusBadRequest, "Validation errors encountered", errors, log)
+	} else {
+		handlers.WriteLog(c, http.StatusProcessing, "Validation passed, continuing", nil, log)
+	}
+
+	return errors, isError
+}
+
+// GetAll retrieves all policies, could be filtered by feature_code query.
+func (p *PolicyServiceImpl) GetAll(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, p)
+
+	var policies []models.USR_Policy
+	var data interface{}
+
+	query := p.db.Model(&models.USR_Policy{})
+	if featureCode := c.Query("feature_code"); featureCode != "" {
+		query = query.Where("feature_code = ?", featureCode)
+	}
+
+	// Count before pagination is applied
+	var totalRows int64
+	query.Count(&totalRows)
+
+	// Apply pagination if the relevant query parameters are present
+	if c.Query("page") != "" || c.Query("limit") != "" {
+		query = query.Scopes(helpers.Paginate(c))
+	}
+
+	// Apply ordering if the relevant query parameters are present
+	if c.Query("order_by") != "" || c.Query("order") != "" {
+		allowedOrderFields := []string{"id", "name", "feature_code", "effect", "is_active", "created_at", "updated_at"}
+		query = query.Scopes(helpers.Order(c, allowedOrderFields))
+	}
+
+	if err := query.Find(&policies).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Getting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	data = dtos.ToUSRPolicyDTOs(policies)
+	if c.Query("page") != "" || c.Query("limit") != "" {
+		data = helpers.GeneratePaginatedQuery(c, totalRows, dtos.ToUSRPolicyDTOs(policies))
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Success Getting All Policies Data",
+		Data:    data,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// GetByID retrieves a policy by its ID.
+func (p *PolicyServiceImpl) GetByID(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, p)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid ID",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	var policy models.USR_Policy
+	result := p.db.Limit(1).Where("id = ?", id).Find(&policy)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "Policy not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Success Getting Policy Data",
+		Data:    dtos.ToUSRPolicyDTO(policy),
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// AddData adds a new policy to the database.
+func (p *PolicyServiceImpl) AddData(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, p)
+
+	var policyDTO dtos.InputUSRPolicyDTO
+	if err := c.ShouldBindJSON(&policyDTO); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(policyDTO); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, policyDTO)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	policy := dtos.InputToUSRPolicyModel(policyDTO)
+
+	// Check and validate input that cannot be validate by golang validator
+	if errors, errorHappen := p.inputValidator(policy, "POST", c); errorHappen {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	if err := p.db.Create(&policy).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Creating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	helpers.InvalidatePolicies()
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusCreated,
+		Message: "Policy Created Successfully",
+		Data:    dtos.ToUSRPolicyDTO(policy),
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// UpdateData updates an existing policy in the database.
+func (p *PolicyServiceImpl) UpdateData(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, p)
+
+	var policyDTO dtos.InputUSRPolicyDTO
+	if err := c.ShouldBindJSON(&policyDTO); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Check Params Validity
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid ID",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	// Check Policy Existence
+	var policy models.USR_Policy
+	result := p.db.Limit(1).Where("id = ?", id).Find(&policy)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "Policy not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(policyDTO); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, policyDTO)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	input := dtos.InputToUSRPolicyModel(policyDTO)
+	input.ID = policy.ID
+	if policyDTO.IsActive == nil {
+		input.IsActive = policy.IsActive
+	}
+
+	// Check and validate input that cannot be validate by golang validator
+	if errors, errorHappen := p.inputValidator(input, "PUT", c); errorHappen {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	policy.Name = input.Name
+	policy.Description = input.Description
+	policy.FeatureCode = input.FeatureCode
+	policy.Effect = input.Effect
+	policy.Expression = input.Expression
+	policy.IsActive = input.IsActive
+
+	if err := p.db.Save(&policy).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Updating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	helpers.InvalidatePolicies()
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Policy Updated Successfully",
+		Data:    dtos.ToUSRPolicyDTO(policy),
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// DeleteData deletes a policy from the database.
+func (p *PolicyServiceImpl) DeleteData(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, p)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid ID",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	// Check Policy Existence
+	var policy models.USR_Policy
+	result := p.db.Limit(1).Where("id = ?", id).Find(&policy)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "Policy not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	if err := p.db.Delete(&policy).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Deleting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	helpers.InvalidatePolicies()
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Policy Deleted Successfully",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// DryRun evaluate the policies of a feature for a user without calling the endpoint and explain
+// the decision. Unsaved expression in the input is evaluated together with the saved policies.
+func (p *PolicyServiceImpl) DryRun(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, p)
+
+	var input dtos.InputPolicyDryRunDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	permission, err := helpers.ResolveUserPermission(p.db, input.UserID)
+	if err == gorm.ErrRecordNotFound {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "User not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to resolve user permission",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Other features of the route are alternatives, like the route check one of them is enough
+	features := []string{input.FeatureCode}
+	for _, code := range input.OtherFeatureCodes {
+		if len(helpers.IntersectFeatures(features, []string{code})) == 0 {
+			features = append(features, code)
+		}
+	}
+
+	policies, err := helpers.GetActivePolicies(p.db, features)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Getting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+	if input.Expression != "" {
+		effect := input.Effect
+		if effect == "" {
+			effect = models.PolicyEffectAllow
+		}
+		policies = append(policies, models.USR_Policy{Name: "(dry run)", FeatureCode: input.FeatureCode, Effect: effect, Expression: input.Expression, IsActive: true})
+	}
+
+	environment := helpers.PolicyEnvironment(c, time.Now())
+	for key, value := range input.Environment {
+		environment[key] = value
+	}
+
+	subject := helpers.PolicySubject(permission)
+	subject["impersonated"] = false
+	subject["api_key"] = false
+
+	// Resource is loaded by module of each feature
+	featureAttributes := func(feature string) (map[string]interface{}, error) {
+		resource, err := helpers.LoadPolicyResource(p.db, []string{feature}, input.ResourceID)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range input.Resource {
+			resource[key] = value
+		}
+		return map[string]interface{}{
+			"subject":  subject,
+			"resource": resource,
+			"env":      environment,
+		}, nil
+	}
+
+	attributes, err := featureAttributes(input.FeatureCode)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to load resource",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Policies are only evaluated after feature and status check passed, features the user
+	// doesn't hold are still evaluated to explain the policies
+	granted := helpers.IntersectFeatures(features, permission.Features)
+	hasFeature := len(granted) != 0
+	evaluated := granted
+	if !hasFeature {
+		evaluated = features
+	}
+	decision, err := helpers.EvaluateFeaturePolicies(policies, evaluated, featureAttributes)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to load resource",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	allowed := decision.Allowed && hasFeature && permission.Status == models.UserStatusActive
+	reason := decision.Reason
+	switch {
+	case permission.Status != models.UserStatusActive:
+		reason = "User account is not active"
+	case !hasFeature:
+		reason = fmt.Sprintf("User doesn't have feature %s", strings.Join(features, " or "))
+	}
+	if !allowed {
+		decision.Features = []string{}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Success Evaluating Policies",
+		Data: dtos.PolicyDryRunDTO{
+			Allowed:    allowed,
+			Reason:     reason,
+			HasFeature: hasFeature,
+			Features:   decision.Features,
+			Attributes: attributes,
+			Policies:   decision.Policies,
+		},
+		Err: nil,
+		Log: log,
+	}
+}
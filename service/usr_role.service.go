@@ -55,6 +55,14 @@ func (r *RoleServiceImpl) inputValidator(model models.USR_Role, method string, c
 		is_error = true
 	}
 
+	// Check parent role existence and inheritance cycle
+	if model.ParentID != nil {
+		if err := helpers.ValidateRoleParent(r.db, model.ID, *model.ParentID); err != nil {
+			errors["errors"]["parent_id"] = err.Error()
+			is_error = true
+		}
+	}
+
 	if is_error {
 		handlers.WriteLog(c, http.StatusBadRequest, "Validation errors encountered", errors, log)
 	} else {
@@ -64,6 +72,54 @@ func (r *RoleServiceImpl) inputValidator(model models.USR_Role, method string, c
 	return errors, is_error
 }
 
+// Convert role to detail DTO with every feature held by the role, direct or implied by granted
+// module and parent role
+func (r *RoleServiceImpl) toRoleDTO(role models.USR_Role) (dtos.USRRoleDTO, error) {
+	roleDTO := dtos.ToUSRRoleDTO(role)
+
+	grants, err := helpers.GetRoleFeatureGrants(r.db.Preload("Features.Module"), role)
+	if err != nil {
+		return roleDTO, err
+	}
+
+	moduleMap := make(map[uint]int)
+	for _, grant := range grants {
+		feature := grant.Feature
+		index, exist := moduleMap[feature.ModuleID]
+		if !exist {
+			index = len(roleDTO.EffectiveModules)
+			moduleMap[feature.ModuleID] = index
+			roleDTO.EffectiveModules = append(roleDTO.EffectiveModules, dtos.USRModuleWithFeatureGrantsDTO{
+				ID:       feature.Module.ID,
+				Name:     feature.Module.Name,
+				ParentID: feature.Module.ParentID,
+				Features: []dtos.USRFeatureGrantDTO{},
+			})
+		}
+
+		module := &roleDTO.EffectiveModules[index]
+		module.Features = append(module.Features, dtos.USRFeatureGrantDTO{
+			ID:          feature.ID,
+			ModuleID:    feature.ModuleID,
+			Code:        feature.Code,
+			Name:        feature.Name,
+			Direct:      grant.Source == helpers.GrantSourceFeature,
+			Source:      grant.Source,
+			GrantedByID: grant.GrantedByID,
+			GrantedBy:   grant.GrantedBy,
+		})
+	}
+
+	return roleDTO, nil
+}
+
+// Convert role that has been saved, effective features is left empty when failed to be resolved
+// since the change is already saved
+func (r *RoleServiceImpl) toSavedRoleDTO(role models.USR_Role) dtos.USRRoleDTO {
+	roleDTO, _ := r.toRoleDTO(role)
+	return roleDTO
+}
+
 // GetAllRoles retrieves all roles from the database and returns them in a ServiceResponseWithLogging.
 func (r *RoleServiceImpl) GetAll(c *gin.Context) handlers.ServiceResponseWithLogging {
 	log := helpers.CreateLog(c, r)
@@ -136,7 +192,7 @@ func (r *RoleServiceImpl) GetByID(c *gin.Context) handlers.ServiceResponseWithLo
 	var role models.USR_Role
 
 	// Fetch the role from the database by ID with preloaded features and modules
-	result := r.db.Preload("Features").Preload("Features.Module").Limit(1).Where("id = ?", id).Find(&role)
+	result := r.db.Preload("Features").Preload("Features.Module").Preload("Modules").Limit(1).Where("id = ?", id).Find(&role)
 	if result.Error != nil || result.RowsAffected == 0 {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusNotFound,
@@ -147,8 +203,17 @@ func (r *RoleServiceImpl) GetByID(c *gin.Context) handlers.ServiceResponseWithLo
 		}
 	}
 
-	// Convert role to DTO
-	roleDTO := dtos.ToUSRRoleDTO(role)
+	// Convert role to DTO with direct and implied features
+	roleDTO, err := r.toRoleDTO(role)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Getting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
 
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusOK,
@@ -211,7 +276,20 @@ func (r *RoleServiceImpl) AddData(c *gin.Context) handlers.ServiceResponseWithLo
 		}
 	}
 
+	// Fetch granted modules from the database
+	var modules []*models.USR_Module
+	if err := r.db.Where("id IN ?", roleDTO.Modules).Find(&modules).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Modules Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
 	role.Features = features
+	role.Modules = modules
 
 	// Add the role to the database
 	if err := r.db.Create(&role).Error; err != nil {
@@ -227,7 +305,7 @@ func (r *RoleServiceImpl) AddData(c *gin.Context) handlers.ServiceResponseWithLo
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusCreated,
 		Message: "Role Created Successfully",
-		Data:    dtos.ToUSRRoleDTO(role),
+		Data:    r.toSavedRoleDTO(role),
 		Err:     nil,
 		Log:     log,
 	}
@@ -265,7 +343,7 @@ func (r *RoleServiceImpl) UpdateData(c *gin.Context) handlers.ServiceResponseWit
 	}
 
 	// Check Role Existence
-	result := r.db.Preload("Features").Limit(1).Where("id = ?", id).Find(&role)
+	result := r.db.Preload("Features").Preload("Modules").Limit(1).Where("id = ?", id).Find(&role)
 	if result.Error != nil || result.RowsAffected == 0 {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusNotFound,
@@ -315,9 +393,26 @@ func (r *RoleServiceImpl) UpdateData(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
+	// Fetch granted modules from the database
+	var modules []*models.USR_Module
+	if err := r.db.Where("id IN ?", roleDTO.Modules).Find(&modules).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Modules Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
 	// Update the role fields
 	role.Name = roleDTO.Name
 	role.IsAdministrative = roleDTO.IsAdministrative
+	role.RequireTwoFactor = roleDTO.RequireTwoFactor
+	if roleDTO.DataScope != "" {
+		role.DataScope = roleDTO.DataScope
+	}
+	role.ParentID = roleDTO.ParentID
 
 	// Update role features
 	// Set new features directly
@@ -331,6 +426,17 @@ func (r *RoleServiceImpl) UpdateData(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
+	// Set new granted modules directly
+	if err := r.db.Model(&role).Association("Modules").Replace(modules); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Updating Role Modules Data",
+			Data:    nil,
+			Err:     err,
+			Log:     log,
+		}
+	}
+
 	// Save the updated role to the database
 	if err := r.db.Save(&role).Error; err != nil {
 		return handlers.ServiceResponseWithLogging{
@@ -342,10 +448,20 @@ func (r *RoleServiceImpl) UpdateData(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
+	// Users holding the role must resolve their features again, users of child roles inherit
+	// the features as well
+	var childCount int64
+	r.db.Model(&models.USR_Role{}).Where("parent_id = ?", role.ID).Count(&childCount)
+	if childCount > 0 {
+		helpers.InvalidatePermissions()
+	} else {
+		helpers.InvalidateRolePermission(role.ID)
+	}
+
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusOK,
 		Message: "Role Updated Successfully",
-		Data:    dtos.ToUSRRoleDTO(role),
+		Data:    r.toSavedRoleDTO(role),
 		Err:     nil,
 		Log:     log,
 	}
@@ -380,6 +496,19 @@ func (r *RoleServiceImpl) DeleteData(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
+	// Role inherited by other roles can't be deleted, the child roles would silently lose features
+	var childCount int64
+	r.db.Model(&models.USR_Role{}).Where("parent_id = ?", role.ID).Count(&childCount)
+	if childCount > 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Role is parent of other roles",
+			Data:    nil,
+			Err:     fmt.Sprintf("Role %s is inherited by %d role(s)", role.Name, childCount),
+			Log:     log,
+		}
+	}
+
 	// Delete the role from the database
 	if err := r.db.Delete(&models.USR_Role{}, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -400,6 +529,8 @@ func (r *RoleServiceImpl) DeleteData(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
+	helpers.InvalidateRolePermission(role.ID)
+
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusOK,
 		Message: "Role Deleted Successfully",
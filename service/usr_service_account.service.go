@@ -0,0 +1,427 @@
+package service
+
+import (
+	"fmt"
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ServiceAccountService defines the methods for the service account and api key service.
+type ServiceAccountService interface {
+	GetAll(c *gin.Context) handlers.ServiceResponseWithLogging
+	AddData(c *gin.Context) handlers.ServiceResponseWithLogging
+	DeleteData(c *gin.Context) handlers.ServiceResponseWithLogging
+	GetAPIKeys(c *gin.Context) handlers.ServiceResponseWithLogging
+	AddAPIKey(c *gin.Context) handlers.ServiceResponseWithLogging
+	RevokeAPIKey(c *gin.Context) handlers.ServiceResponseWithLogging
+}
+
+// ServiceAccountServiceImpl is the implementation of the ServiceAccountService interface.
+type ServiceAccountServiceImpl struct {
+	db *gorm.DB
+}
+
+// ServiceAccountServiceConstructor creates a new instance of ServiceAccountServiceImpl.
+func ServiceAccountServiceConstructor(db *gorm.DB) ServiceAccountService {
+	return &ServiceAccountServiceImpl{db: db}
+}
+
+// Find service account by id param, second return value is false when not found
+func (s *ServiceAccountServiceImpl) findAccount(c *gin.Context, preloads ...string) (models.USR_User, bool) {
+	var account models.USR_User
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return account, false
+	}
+
+	query := s.db
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+
+	result := query.Limit(1).Where("id = ? AND is_service_account = ?", id, true).Find(&account)
+	return account, result.Error == nil && result.RowsAffected != 0
+}
+
+// Validate service account input that cannot check by validator v10 package
+func (s *ServiceAccountServiceImpl) inputValidator(input dtos.InputUSRServiceAccountDTO, c *gin.Context) (map[string]map[string]string, bool) {
+	errors := map[string]map[string]string{"errors": {}}
+	log := helpers.CreateLog(c, s)
+
+	// Check username and email duplication
+	var duplicate models.USR_User
+	if result := s.db.Limit(1).Where("username = ?", input.Username).Find(&duplicate); result.Error != nil || result.RowsAffected >= 1 {
+		errors["errors"]["username"] = fmt.Sprintf("Username %s already exist", input.Username)
+	}
+	if result := s.db.Limit(1).Where("email = ?", input.Email).Find(&duplicate); result.Error != nil || result.RowsAffected >= 1 {
+		errors["errors"]["email"] = fmt.Sprintf("User email %s already exist", input.Email)
+	}
+
+	// Check if role exist
+	var role models.USR_Role
+	if result := s.db.Limit(1).Where("id = ?", input.RoleID).Find(&role); result.Error != nil || result.RowsAffected == 0 {
+		errors["errors"]["role_id"] = fmt.Sprintf("Role with id %d not found", input.RoleID)
+	}
+
+	isError := len(errors["errors"]) != 0
+	if isError {
+		handlers.WriteLog(c, http.StatusBadRequest, "Validation errors encountered", errors, log)
+	} else {
+		handlers.WriteLog(c, http.StatusProcessing, "Validation passed, continuing", nil, log)
+	}
+
+	return errors, isError
+}
+
+// GetAll retrieves all service accounts.
+func (s *ServiceAccountServiceImpl) GetAll(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, s)
+
+	var accounts []models.USR_User
+	if err := s.db.Preload("Role").Where("is_service_account = ?", true).Order("id").Find(&accounts).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Getting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Success Getting All Service Accounts Data",
+		Data:    dtos.ToUSRServiceAccountDTOs(accounts),
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// AddData create new service account, service account has no password and can't login.
+func (s *ServiceAccountServiceImpl) AddData(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, s)
+	var input dtos.InputUSRServiceAccountDTO
+
+	if err := c.ShouldBind(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	// Check and validate input that cannot be validate by golang validator
+	if errors, errorHappen := s.inputValidator(input, c); errorHappen {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	account := models.USR_User{
+		Username:         input.Username,
+		Name:             input.Name,
+		Email:            input.Email,
+		RoleID:           input.RoleID,
+		Roles:            []*models.USR_Role{{ID: input.RoleID}},
+		IsServiceAccount: true,
+	}
+
+	if err := s.db.Omit("Roles.*").Create(&account).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Creating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	s.db.Preload("Role").First(&account, account.ID)
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusCreated,
+		Message: "Service Account Created Successfully",
+		Data:    dtos.ToUSRServiceAccountDTO(account),
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// DeleteData delete service account and revoke every api key it has.
+func (s *ServiceAccountServiceImpl) DeleteData(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, s)
+
+	account, found := s.findAccount(c)
+	if !found {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "Service account not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.USR_APIKey{}).Where("user_id = ? AND revoked_at IS NULL", account.ID).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&account).Error
+	})
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Deleting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	helpers.InvalidateUserPermission(account.ID)
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Service Account Deleted Successfully",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// GetAPIKeys retrieves every api key of a service account, including revoked and expired one.
+func (s *ServiceAccountServiceImpl) GetAPIKeys(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, s)
+
+	account, found := s.findAccount(c, "APIKeys", "APIKeys.Features")
+	if !found {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "Service account not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Success Getting All API Keys Data",
+		Data:    dtos.ToUSRAPIKeyDTOs(account.APIKeys),
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// AddAPIKey create api key for service account, key features must be subset of the account role
+// features. The key is only shown in this response.
+func (s *ServiceAccountServiceImpl) AddAPIKey(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, s)
+	var input dtos.InputUSRAPIKeyDTO
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Input",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Validate input using golang validator
+	if err := handlers.ValidateStruct(input); err != nil {
+		errors := handlers.ValidationErrorHandlerV1(c, err, input)
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	account, found := s.findAccount(c, "Role", "Role.Features", "Roles", "Roles.Features")
+	if !found {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "Service account not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	errors := map[string]map[string]string{"errors": {}}
+
+	// Check key features are owned by the account roles, including inherited features
+	if err := helpers.InheritUserRoleFeatures(s.db, &account); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Getting Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+	roleFeatures := make(map[uint]*models.USR_Feature)
+	for _, role := range account.EffectiveRoles() {
+		for _, feature := range role.Features {
+			roleFeatures[feature.ID] = feature
+		}
+	}
+
+	var features []*models.USR_Feature
+	for _, featureID := range input.Features {
+		feature, exist := roleFeatures[featureID]
+		if !exist {
+			errors["errors"]["features"] = fmt.Sprintf("Feature with id %d is not granted to the service account role", featureID)
+			break
+		}
+		features = append(features, feature)
+	}
+
+	// Check expiry, default lifetime is API_KEY_EXPIRY_DAYS
+	expiresAt := time.Now().AddDate(0, 0, helpers.GetENVIntWithDefault("API_KEY_EXPIRY_DAYS", 90))
+	if input.ExpiresAt != nil {
+		expiresAt = *input.ExpiresAt
+	}
+	if !expiresAt.After(time.Now()) {
+		errors["errors"]["expires_at"] = "Expiry time must be in the future"
+	}
+
+	if len(errors["errors"]) != 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Error Invalid Data",
+			Data:    nil,
+			Err:     errors,
+			Log:     log,
+		}
+	}
+
+	key, prefix, err := helpers.GenerateAPIKey()
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to generate API key",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	apiKey := models.USR_APIKey{
+		UserID:    account.ID,
+		Name:      input.Name,
+		Prefix:    prefix,
+		KeyHash:   helpers.HashToken(key),
+		ExpiresAt: &expiresAt,
+		Features:  features,
+	}
+
+	if err := s.db.Create(&apiKey).Error; err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Creating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusCreated,
+		Message: "API Key Created Successfully, Store The Key Because It Won't Be Shown Again",
+		Data: dtos.USRAPIKeyCreatedDTO{
+			USRAPIKeyDTO: dtos.ToUSRAPIKeyDTO(apiKey),
+			Key:          key,
+		},
+		Err: nil,
+		Log: log,
+	}
+}
+
+// RevokeAPIKey revoke api key of service account, revoked key is kept for audit.
+func (s *ServiceAccountServiceImpl) RevokeAPIKey(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, s)
+
+	account, found := s.findAccount(c)
+	if !found {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "Service account not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	// Check Params Validity
+	keyID, err := strconv.Atoi(c.Param("key_id"))
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid ID",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	result := s.db.Model(&models.USR_APIKey{}).Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, account.ID).Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Updating Data",
+			Data:    nil,
+			Err:     result.Error.Error(),
+			Log:     log,
+		}
+	}
+	if result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "API key not found or already revoked",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "API Key Revoked Successfully",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
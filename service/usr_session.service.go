@@ -0,0 +1,286 @@
+package service
+
+import (
+	"jxb-eprocurement/handlers"
+	"jxb-eprocurement/handlers/dtos"
+	"jxb-eprocurement/helpers"
+	"jxb-eprocurement/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SessionService defines the methods for listing and revoking login sessions.
+type SessionService interface {
+	GetMine(c *gin.Context) handlers.ServiceResponseWithLogging
+	RevokeMine(c *gin.Context) handlers.ServiceResponseWithLogging
+	RevokeAllMine(c *gin.Context) handlers.ServiceResponseWithLogging
+	GetByUser(c *gin.Context) handlers.ServiceResponseWithLogging
+	RevokeByUser(c *gin.Context) handlers.ServiceResponseWithLogging
+}
+
+// SessionServiceImpl is the implementation of the SessionService interface.
+type SessionServiceImpl struct {
+	db *gorm.DB
+}
+
+// SessionServiceConstructor creates a new instance of SessionServiceImpl.
+func SessionServiceConstructor(db *gorm.DB) SessionService {
+	return &SessionServiceImpl{db: db}
+}
+
+// Get active sessions of the user, latest activity first
+func (s *SessionServiceImpl) activeSessions(userID uint) ([]models.USR_Session, error) {
+	var sessions []models.USR_Session
+	err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("COALESCE(last_activity_at, created_at) DESC").
+		Find(&sessions).Error
+
+	return sessions, err
+}
+
+// Revoke one active session of the user, return gorm.ErrRecordNotFound when the session
+// doesn't belong to the user or already inactive
+func (s *SessionServiceImpl) revokeUserSession(userID uint, sessionID uint, reason string) error {
+	var session models.USR_Session
+	result := s.db.Limit(1).Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, userID, time.Now()).Find(&session)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return revokeSession(s.db, session.ID, reason)
+}
+
+// Get session id of the access token used in the request, 0 for api key request
+func currentSessionID(c *gin.Context) uint {
+	var claims dtos.Claims
+	helpers.GetClaimsPayload(c, &claims)
+	return claims.SessionID
+}
+
+// GetMine list active sessions of the current user.
+func (s *SessionServiceImpl) GetMine(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, s)
+
+	var userPayload models.USR_User
+	helpers.GetUserPayload(c, &userPayload)
+
+	sessions, err := s.activeSessions(userPayload.ID)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Fetching Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Sessions Fetched Successfully",
+		Data:    dtos.ToUSRSessionDTOs(sessions, currentSessionID(c)),
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// RevokeMine sign out one session of the current user, e.g. lost device.
+func (s *SessionServiceImpl) RevokeMine(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, s)
+
+	// Check Params Validity
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid ID",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	var userPayload models.USR_User
+	helpers.GetUserPayload(c, &userPayload)
+
+	err = s.revokeUserSession(userPayload.ID, uint(id), "Revoked by user")
+	if err == gorm.ErrRecordNotFound {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "Session not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Revoking Session",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Session Revoked Successfully",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// RevokeAllMine sign out every session of the current user, including the current one.
+func (s *SessionServiceImpl) RevokeAllMine(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, s)
+
+	var userPayload models.USR_User
+	helpers.GetUserPayload(c, &userPayload)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return helpers.RevokeUserTokens(tx, userPayload.ID, "Revoked by user")
+	})
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Revoking Sessions",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Sessions Revoked Successfully",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// GetByUser list active sessions of a user, used by admin.
+func (s *SessionServiceImpl) GetByUser(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, s)
+
+	// Check Params Validity
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid ID",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Check User Existence, user outside data scope is treated as not found
+	var user models.USR_User
+	result := s.db.Scopes(helpers.ScopeData(helpers.GetDataScope(c), "id")).Limit(1).Where("id = ?", id).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "User not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	sessions, err := s.activeSessions(user.ID)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Fetching Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Sessions Fetched Successfully",
+		Data:    dtos.ToUSRSessionDTOs(sessions, currentSessionID(c)),
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// RevokeByUser sign out one session of a user, used by admin.
+func (s *SessionServiceImpl) RevokeByUser(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, s)
+
+	// Check Params Validity
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid ID",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+	sessionID, err := strconv.Atoi(c.Param("session_id"))
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid Session ID",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Check User Existence, user outside data scope is treated as not found
+	var user models.USR_User
+	result := s.db.Scopes(helpers.ScopeData(helpers.GetDataScope(c), "id")).Limit(1).Where("id = ?", id).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "User not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	err = s.revokeUserSession(user.ID, uint(sessionID), "Revoked by admin")
+	if err == gorm.ErrRecordNotFound {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "Session not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Revoking Session",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Session Revoked Successfully",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
@@ -8,9 +8,9 @@ import (
 	"jxb-eprocurement/models"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -23,6 +23,10 @@ type UserService interface {
 	DeleteData(c *gin.Context) handlers.ServiceResponseWithLogging
 	ResetPass(c *gin.Context) handlers.ServiceResponseWithLogging
 	ChangePass(c *gin.Context) handlers.ServiceResponseWithLogging
+	RevokeSessions(c *gin.Context) handlers.ServiceResponseWithLogging
+	Unlock(c *gin.Context) handlers.ServiceResponseWithLogging
+	Approve(c *gin.Context) handlers.ServiceResponseWithLogging
+	Impersonate(c *gin.Context) handlers.ServiceResponseWithLogging
 }
 
 // UserServiceImpl is the implementation of the UserService interface.
@@ -59,12 +63,30 @@ func (u *UserServiceImpl) inputValidator(model models.USR_User, method string, c
 
 	}
 
-	// Check if role exist
-	var role models.USR_Role
-	result = u.db.Table("usr_roles").Where("id = ?", model.RoleID).Limit(1).Find(&role)
-	if result.Error != nil || result.RowsAffected == 0 {
-		errors["errors"]["role_id"] = fmt.Sprintf("Role with id %d not found", model.RoleID)
-		is_error = true
+	// Check password against password policy, password only set on create
+	if method == "POST" {
+		if message := validateNewPassword(u.db, model, model.Password); message != "" {
+			errors["errors"]["password"] = message
+			is_error = true
+		}
+	}
+
+	// Check user has at least one role and every role exist, roles are ignored when non admin update user so skip it
+	var rolePayload models.USR_Role
+	helpers.GetRolePayload(c, &rolePayload)
+	if method == "POST" || rolePayload.IsAdministrative {
+		if len(model.Roles) == 0 {
+			errors["errors"]["role_ids"] = "User must have at least one role"
+			is_error = true
+		}
+		for _, role := range model.Roles {
+			var roleInstance models.USR_Role
+			result = u.db.Table("usr_roles").Where("id = ? AND deleted_at IS NULL", role.ID).Limit(1).Find(&roleInstance)
+			if result.Error != nil || result.RowsAffected == 0 {
+				errors["errors"]["role_ids"] = fmt.Sprintf("Role with id %d not found", role.ID)
+				is_error = true
+			}
+		}
 	}
 
 	if is_error {
@@ -83,10 +105,22 @@ func (u *UserServiceImpl) GetAll(c *gin.Context) handlers.ServiceResponseWithLog
 	var users []models.USR_User
 	var data interface{}
 
-	query := u.db.Preload("Role", func(db *gorm.DB) *gorm.DB {
+	// Only users within data scope of the caller are listed
+	scope := helpers.ScopeData(helpers.GetDataScope(c), "id")
+
+	query := u.db.Scopes(scope).Preload("Role", func(db *gorm.DB) *gorm.DB {
 		return db.Select("id", "name").Unscoped()
+	}).Preload("Roles", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "name")
 	})
 
+	// Filter by account status, e.g. vendor waiting for approval
+	countQuery := u.db.Model(&models.USR_User{}).Scopes(scope)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+		countQuery = countQuery.Where("status = ?", status)
+	}
+
 	// Check if using pagination and order in query
 	// Apply pagination if the relevant query parameters are present
 	if c.Query("page") != "" || c.Query("limit") != "" {
@@ -97,7 +131,7 @@ func (u *UserServiceImpl) GetAll(c *gin.Context) handlers.ServiceResponseWithLog
 	if c.Query("order_by") != "" || c.Query("order") != "" {
 		// allowedOrderedFields is whitelist of option that user could use to order,
 		// this is also used to prevent sql injection on order
-		allowedOrderFields := []string{"id", "name", "email", "role_id", "created_at", "updated_at"}
+		allowedOrderFields := []string{"id", "name", "email", "role_id", "status", "created_at", "updated_at"}
 		query = query.Scopes(helpers.Order(c, allowedOrderFields))
 	}
 
@@ -118,7 +152,7 @@ func (u *UserServiceImpl) GetAll(c *gin.Context) handlers.ServiceResponseWithLog
 	// Setup data for paginated result
 	if c.Query("page") != "" || c.Query("limit") != "" {
 		var totalRows int64
-		u.db.Model(&models.USR_User{}).Count(&totalRows)
+		countQuery.Count(&totalRows)
 		data = helpers.GeneratePaginatedQuery(c, totalRows, dtos.MinimalUserDTOToInterfaceSlice(userDTOs))
 	}
 
@@ -150,7 +184,7 @@ func (u *UserServiceImpl) GetByID(c *gin.Context) handlers.ServiceResponseWithLo
 	var user models.USR_User
 
 	// Fetch the user from the database by ID
-	result := u.db.Preload("Role").Preload("Role.Features").Preload("Role.Features.Module").Limit(1).Where("id = ?", id).Omit("password").Find(&user)
+	result := u.db.Scopes(helpers.ScopeData(helpers.GetDataScope(c), "id")).Preload("Role").Preload("Role.Features").Preload("Role.Features.Module").Preload("Roles").Preload("Roles.Features").Preload("Roles.Features.Module").Limit(1).Where("id = ?", id).Omit("password").Find(&user)
 	if result.Error != nil || result.RowsAffected == 0 {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusNotFound,
@@ -215,20 +249,10 @@ func (u *UserServiceImpl) AddData(c *gin.Context) handlers.ServiceResponseWithLo
 	}
 
 	// Add the user to the database
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userModel.Password), bcrypt.DefaultCost)
+	err := u.db.Transaction(func(tx *gorm.DB) error {
+		return saveUserPassword(tx, &userModel, userModel.Password)
+	})
 	if err != nil {
-		return handlers.ServiceResponseWithLogging{
-			Status:  http.StatusBadRequest,
-			Message: "Failed to hash password",
-			Data:    nil,
-			Err:     err.Error(),
-			Log:     log,
-		}
-	}
-
-	userModel.Password = string(hashedPassword)
-
-	if err := u.db.Create(&userModel).Error; err != nil {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusInternalServerError,
 			Message: "Error Creating Data",
@@ -298,8 +322,8 @@ func (u *UserServiceImpl) UpdateData(c *gin.Context) handlers.ServiceResponseWit
 	input.ID = uint(id)
 	data.ID = uint(id)
 
-	// Check User Existence
-	result := u.db.Limit(1).Where("id = ?", id).Find(&user)
+	// Check User Existence, user outside data scope is treated as not found
+	result := u.db.Scopes(helpers.ScopeData(helpers.GetDataScope(c), "id")).Limit(1).Where("id = ?", id).Find(&user)
 	if result.Error != nil || result.RowsAffected == 0 {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusNotFound,
@@ -338,10 +362,24 @@ func (u *UserServiceImpl) UpdateData(c *gin.Context) handlers.ServiceResponseWit
 	user.Username = data.Username
 	user.Name = data.Name
 	user.Email = data.Email
-	user.RoleID = data.RoleID
 
-	// Save the updated user to the database
-	if err := u.db.Save(&user).Error; err != nil {
+	// Roles and organization unit decide what the user can access, only administrator can change it
+	if rolePayload.IsAdministrative {
+		user.RoleID = data.RoleID
+		user.OrganizationUnit = data.OrganizationUnit
+	}
+
+	// Save the updated user and replace the roles
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		if !rolePayload.IsAdministrative {
+			return nil
+		}
+		return tx.Model(&user).Omit("Roles.*").Association("Roles").Replace(data.Roles)
+	})
+	if err != nil {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusInternalServerError,
 			Message: "Error Updating Data",
@@ -351,6 +389,9 @@ func (u *UserServiceImpl) UpdateData(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
+	// User role might be changed
+	helpers.InvalidateUserPermission(user.ID)
+
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusOK,
 		Message: "User Updated Successfully",
@@ -391,9 +432,9 @@ func (u *UserServiceImpl) DeleteData(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
-	// Check User Existence
+	// Check User Existence, user outside data scope is treated as not found
 	var user models.USR_User
-	result := u.db.Limit(1).Where("id = ?", id).Find(&user)
+	result := u.db.Scopes(helpers.ScopeData(helpers.GetDataScope(c), "id")).Limit(1).Where("id = ?", id).Find(&user)
 	if result.Error != nil || result.RowsAffected == 0 {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusNotFound,
@@ -404,8 +445,15 @@ func (u *UserServiceImpl) DeleteData(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
-	// Delete the user from the database
-	if err := u.db.Delete(&models.USR_User{}, id).Error; err != nil {
+	// Delete the user from the database and revoke every token the user still hold
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.USR_User{}, id).Error; err != nil {
+			return err
+		}
+
+		return helpers.RevokeUserTokens(tx, uint(id), "User deleted")
+	})
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return handlers.ServiceResponseWithLogging{
 				Status:  http.StatusNotFound,
@@ -424,6 +472,8 @@ func (u *UserServiceImpl) DeleteData(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
+	helpers.InvalidateUserPermission(user.ID)
+
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusOK,
 		Message: "User Deleted Successfully",
@@ -472,9 +522,9 @@ func (u *UserServiceImpl) ResetPass(c *gin.Context) handlers.ServiceResponseWith
 		}
 	}
 
-	// Check User Existence
+	// Check User Existence, user outside data scope is treated as not found
 	var user models.USR_User
-	result := u.db.Limit(1).Where("id = ?", id).Find(&user)
+	result := u.db.Scopes(helpers.ScopeData(helpers.GetDataScope(c), "id")).Limit(1).Where("id = ?", id).Find(&user)
 	if result.Error != nil || result.RowsAffected == 0 {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusNotFound,
@@ -492,6 +542,8 @@ func (u *UserServiceImpl) ResetPass(c *gin.Context) handlers.ServiceResponseWith
 	if input.Password != input.RePassword {
 		errors["errors"]["password"] = "Re-Password and Password are different"
 		errors["errors"]["re_password"] = "Re-Password and Password are different"
+	} else if message := validateNewPassword(u.db, user, input.Password); message != "" {
+		errors["errors"]["password"] = message
 	}
 
 	if len(errors["errors"]) != 0 {
@@ -504,22 +556,15 @@ func (u *UserServiceImpl) ResetPass(c *gin.Context) handlers.ServiceResponseWith
 		}
 	}
 
-	// Add the user to the database
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return handlers.ServiceResponseWithLogging{
-			Status:  http.StatusBadRequest,
-			Message: "Failed to hash password",
-			Data:    nil,
-			Err:     err.Error(),
-			Log:     log,
+	// Save the new user password to the database and revoke every token the user still hold
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveUserPassword(tx, &user, input.Password); err != nil {
+			return err
 		}
-	}
-
-	user.Password = string(hashedPassword)
 
-	// Save the new user password to the database
-	if err := u.db.Save(&user).Error; err != nil {
+		return helpers.RevokeUserTokens(tx, user.ID, "Password reset by admin")
+	})
+	if err != nil {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusInternalServerError,
 			Message: "Error Updating Data",
@@ -593,9 +638,9 @@ func (u *UserServiceImpl) ChangePass(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
-	// Check User Existence
+	// Check User Existence, user outside data scope is treated as not found
 	var user models.USR_User
-	result := u.db.Limit(1).Where("id = ?", id).Find(&user)
+	result := u.db.Scopes(helpers.ScopeData(helpers.GetDataScope(c), "id")).Limit(1).Where("id = ?", id).Find(&user)
 	if result.Error != nil || result.RowsAffected == 0 {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusNotFound,
@@ -616,8 +661,12 @@ func (u *UserServiceImpl) ChangePass(c *gin.Context) handlers.ServiceResponseWit
 	}
 
 	// Check if old password is correct
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.OldPassword)); err != nil {
+	if !helpers.VerifyPassword(user.Password, input.OldPassword) {
 		errors["errors"]["old_password"] = "The old password is incorrect"
+	} else if _, exist := errors["errors"]["password"]; !exist {
+		if message := validateNewPassword(u.db, user, input.Password); message != "" {
+			errors["errors"]["password"] = message
+		}
 	}
 
 	if len(errors["errors"]) != 0 {
@@ -630,22 +679,169 @@ func (u *UserServiceImpl) ChangePass(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
-	// Add the user to the database
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	// Save the new user password to the database
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		return saveUserPassword(tx, &user, input.Password)
+	})
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Updating Data",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "User Password Reset Successfully",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// RevokeSessions revoke every session and outstanding token of a user, used by admin
+// to sign out user from every device.
+func (u *UserServiceImpl) RevokeSessions(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, u)
+
+	// Check Params Validity
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusBadRequest,
-			Message: "Failed to hash password",
+			Message: "Invalid ID",
 			Data:    nil,
 			Err:     err.Error(),
 			Log:     log,
 		}
 	}
 
-	user.Password = string(hashedPassword)
+	// Check User Existence, user outside data scope is treated as not found
+	var user models.USR_User
+	result := u.db.Scopes(helpers.ScopeData(helpers.GetDataScope(c), "id")).Limit(1).Where("id = ?", id).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "User not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
 
-	// Save the new user password to the database
-	if err := u.db.Save(&user).Error; err != nil {
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		return helpers.RevokeUserTokens(tx, user.ID, "Revoked by admin")
+	})
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Revoking Sessions",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "User Sessions Revoked Successfully",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// Unlock clear failed login counter and lockout of a user, kept under the user id.
+func (u *UserServiceImpl) Unlock(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, u)
+
+	// Check Params Validity
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid ID",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Check User Existence, user outside data scope is treated as not found
+	var user models.USR_User
+	result := u.db.Scopes(helpers.ScopeData(helpers.GetDataScope(c), "id")).Limit(1).Where("id = ?", id).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "User not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	if err := clearLoginThrottle(u.db, userThrottleKey(user.ID)); err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Error Unlocking User",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "User Unlocked Successfully",
+		Data:    nil,
+		Err:     nil,
+		Log:     log,
+	}
+}
+
+// Approve activate self-registered vendor whose email already verified.
+func (u *UserServiceImpl) Approve(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, u)
+
+	// Check Params Validity
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid ID",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Check User Existence, user outside data scope is treated as not found
+	var user models.USR_User
+	result := u.db.Scopes(helpers.ScopeData(helpers.GetDataScope(c), "id")).Limit(1).Where("id = ?", id).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "User not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	if user.Status != models.UserStatusPendingApproval {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "User is not waiting for approval",
+			Data:    nil,
+			Err:     fmt.Sprintf("User status is %s", user.Status),
+			Log:     log,
+		}
+	}
+
+	if err := u.db.Model(&user).Update("status", models.UserStatusActive).Error; err != nil {
 		return handlers.ServiceResponseWithLogging{
 			Status:  http.StatusInternalServerError,
 			Message: "Error Updating Data",
@@ -655,11 +851,131 @@ func (u *UserServiceImpl) ChangePass(c *gin.Context) handlers.ServiceResponseWit
 		}
 	}
 
+	helpers.InvalidateUserPermission(user.ID)
+
 	return handlers.ServiceResponseWithLogging{
 		Status:  http.StatusOK,
-		Message: "User Password Reset Successfully",
+		Message: "User Approved Successfully",
 		Data:    nil,
 		Err:     nil,
 		Log:     log,
 	}
 }
+
+// Impersonate give administrator time-limited token acting as another user, so support staff
+// can see what the user see. Administrator can't be impersonated and impersonation can't be chained.
+func (u *UserServiceImpl) Impersonate(c *gin.Context) handlers.ServiceResponseWithLogging {
+	log := helpers.CreateLog(c, u)
+
+	// Check Params Validity
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid ID",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	// Impersonation is only started from a real login of the administrator
+	var claims dtos.Claims
+	helpers.GetClaimsPayload(c, &claims)
+	if claims.Id == "" || claims.Act != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusForbidden,
+			Message: "Impersonation is not allowed from this token",
+			Data:    nil,
+			Err:     "Impersonation require administrator login token",
+			Log:     log,
+		}
+	}
+	if uint(id) == claims.UserID {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusBadRequest,
+			Message: "Can't impersonate yourself",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	var actor models.USR_User
+	result := u.db.Limit(1).Where("id = ?", claims.UserID).Find(&actor)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusUnauthorized,
+			Message: "User no longer exist",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	// Check User Existence, user outside data scope is treated as not found
+	var user models.USR_User
+	result = helpers.PreloadUserRoles(u.db).Scopes(helpers.ScopeData(helpers.GetDataScope(c), "id")).Limit(1).Where("id = ?", id).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusNotFound,
+			Message: "User not found",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+
+	if helpers.IsUserAdministrative(user) {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusForbidden,
+			Message: "Impersonating administrator is not allowed",
+			Data:    nil,
+			Err:     "User has administrative role",
+			Log:     log,
+		}
+	}
+	if user.IsServiceAccount {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusForbidden,
+			Message: "Impersonating service account is not allowed",
+			Data:    nil,
+			Err:     nil,
+			Log:     log,
+		}
+	}
+	if message := inactiveUserMessage(user); message != "" {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusForbidden,
+			Message: message,
+			Data:    nil,
+			Err:     fmt.Sprintf("User status is %s", user.Status),
+			Log:     log,
+		}
+	}
+
+	token, impersonationClaims, err := createImpersonationSession(u.db, c, user, actor)
+	if err != nil {
+		return handlers.ServiceResponseWithLogging{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to generate token",
+			Data:    nil,
+			Err:     err.Error(),
+			Log:     log,
+		}
+	}
+
+	handlers.WriteLog(c, http.StatusOK, fmt.Sprintf("Impersonation of user %d (%s) started, session %d", user.ID, user.Username, impersonationClaims.SessionID), nil, log)
+
+	return handlers.ServiceResponseWithLogging{
+		Status:  http.StatusOK,
+		Message: "Impersonation Started",
+		Data: dtos.ImpersonationDTO{
+			Token:     fmt.Sprintf("Bearer %s", token),
+			ExpiresAt: time.Unix(impersonationClaims.ExpiresAt, 0),
+			User:      dtos.ToUSRUserMinimalDTO(user),
+		},
+		Err: nil,
+		Log: log,
+	}
+}